@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	// Ctrl+C прерывает выполняющийся запрос
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	db, err := sql.Open("sqlite3", "./test.db")
 	if err != nil {
 		log.Fatal(err)
@@ -16,7 +24,7 @@ func main() {
 	defer db.Close()
 
 	// Создание таблицы
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT,
 		age INTEGER
@@ -26,13 +34,13 @@ func main() {
 	}
 
 	// Вставка данных
-	_, err = db.Exec("INSERT INTO users (name, age) VALUES (?, ?)", "Alexey", 45)
+	_, err = db.ExecContext(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", "Alexey", 45)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Выборка данных
-	rows, err := db.Query("SELECT name, age FROM users WHERE age > ?", 25)
+	rows, err := db.QueryContext(ctx, "SELECT name, age FROM users WHERE age > ?", 25)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -46,4 +54,8 @@ func main() {
 		}
 		fmt.Printf("Имя: %s, Возраст: %d\n", name, age)
 	}
+	// Отмена контекста во время чтения видна только здесь: Next просто вернёт false
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
 }
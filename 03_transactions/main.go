@@ -1,32 +1,40 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	// Ctrl+C отменяет транзакцию: database/sql откатит её сам
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	db, err := sql.Open("sqlite3", "./test.db")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer tx.Rollback() // откат при панике или ошибке
 
-	_, err = tx.Exec("INSERT INTO users (name, age) VALUES (?, ?)", "Bob", 28)
+	_, err = tx.ExecContext(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", "Bob", 28)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	_, err = tx.Exec("INSERT INTO users (name, age) VALUES (?, ?)", "Charlie", 35)
+	_, err = tx.ExecContext(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", "Charlie", 35)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	// Ctrl+C прерывает выполняющийся запрос
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	db, err := sql.Open("sqlite3", "./test.db")
 	if err != nil {
 		log.Fatal(err)
@@ -19,13 +27,13 @@ func main() {
 	age := 22
 
 	// Подготовленный запрос
-	stmt, err := db.Prepare("INSERT INTO users (name, age) VALUES (?, ?)")
+	stmt, err := db.PrepareContext(ctx, "INSERT INTO users (name, age) VALUES (?, ?)")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(name, age)
+	_, err = stmt.ExecContext(ctx, name, age)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -11,5 +11,5 @@ require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.32 // indirect
-	golang.org/x/text v0.20.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
 )
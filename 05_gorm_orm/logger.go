@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// slowQueryLogger — logger.Interface для GORM: пишет медленные запросы и ошибки,
+// а обычные запросы — только на уровне Info. Заменяет db.Debug(), который печатает всё подряд.
+type slowQueryLogger struct {
+	out       *log.Logger
+	level     logger.LogLevel
+	threshold time.Duration
+}
+
+func newSlowQueryLogger(threshold time.Duration) *slowQueryLogger {
+	return &slowQueryLogger{
+		out:       log.New(os.Stderr, "[gorm] ", log.LstdFlags),
+		level:     logger.Warn,
+		threshold: threshold,
+	}
+}
+
+func (l *slowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *slowQueryLogger) Info(_ context.Context, msg string, args ...any) {
+	if l.level >= logger.Info {
+		l.out.Printf("INFO "+msg, args...)
+	}
+}
+
+func (l *slowQueryLogger) Warn(_ context.Context, msg string, args ...any) {
+	if l.level >= logger.Warn {
+		l.out.Printf("WARN "+msg, args...)
+	}
+}
+
+func (l *slowQueryLogger) Error(_ context.Context, msg string, args ...any) {
+	if l.level >= logger.Error {
+		l.out.Printf("ERROR "+msg, args...)
+	}
+}
+
+// Trace вызывается GORM после каждого запроса
+func (l *slowQueryLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	// «Не найдено» и дубликат — ожидаемые исходы, репозиторий превращает их в доменные ошибки
+	case err != nil && l.level >= logger.Error && !isExpected(err):
+		sql, rows := fc()
+		l.out.Printf("ERROR %s: %v [%s, строк: %s] %s", utils.FileWithLineNum(), err, elapsed, formatRows(rows), sql)
+	case l.threshold > 0 && elapsed > l.threshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.out.Printf("SLOW >= %s %s: [%s, строк: %s] %s", l.threshold, utils.FileWithLineNum(), elapsed, formatRows(rows), sql)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.out.Printf("%s: [%s, строк: %s] %s", utils.FileWithLineNum(), elapsed, formatRows(rows), sql)
+	}
+}
+
+func isExpected(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// rows = -1, когда число строк неизвестно
+func formatRows(rows int64) string {
+	if rows < 0 {
+		return "-"
+	}
+	return fmt.Sprint(rows)
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -12,26 +18,80 @@ type User struct {
 	ID   uint
 	Name string
 	Age  int
+	// Email уникален; у старых строк без email — NULL, уникальности это не мешает
+	Email *string `gorm:"size:100;uniqueIndex"`
 }
 
 func main() {
-	db, err := gorm.Open(sqlite.Open("gorm_test.db"), &gorm.Config{})
+	// WithContext(ctx) передаёт отмену в каждый запрос: Ctrl+C прерывает текущий
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := gorm.Open(sqlite.Open("gorm_test.db"), &gorm.Config{
+		// Ошибки драйвера переводятся в gorm.ErrDuplicatedKey, gorm.ErrForeignKeyViolated и т.п.
+		TranslateError: true,
+		// Медленные запросы и ошибки — в лог, вместо Debug() для каждого запроса
+		Logger: newSlowQueryLogger(200 * time.Millisecond),
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Автомиграция
-	db.AutoMigrate(&User{})
+	if err := db.WithContext(ctx).AutoMigrate(&User{}); err != nil {
+		log.Fatalf("❌ Ошибка миграции: %v", err)
+	}
 
-	// Вставка
-	user := User{Name: "Alexey", Age: 45}
-	db.Debug().Create(&user)
+	repo := NewUserRepository(db)
+
+	// Вставка: повторный запуск упрётся в уникальный email — это ErrUserExists, а не падение
+	email := "alexey@example.com"
+	user := User{Name: "Alexey", Age: 45, Email: &email}
+	err = repo.Create(ctx, &user)
+	switch {
+	case errors.Is(err, ErrUserExists):
+		fmt.Printf("Пользователь %s уже есть\n", email)
+		existing, err := repo.GetByEmail(ctx, email)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		user = *existing
+	case err != nil:
+		log.Fatalf("❌ %v", err)
+	default:
+		fmt.Printf("✅ Создан пользователь id=%d\n", user.ID)
+	}
+
+	// Дубликат явно
+	dup := User{Name: "Alexey 2", Age: 30, Email: &email}
+	if err := repo.Create(ctx, &dup); errors.Is(err, ErrUserExists) {
+		fmt.Printf("Дубликат отклонён: %v\n", err)
+	}
+
+	// Обновление: RowsAffected = 0 — такого пользователя нет
+	if err := repo.UpdateAge(ctx, user.ID, 46); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := repo.UpdateAge(ctx, 999999, 1); errors.Is(err, ErrUserNotFound) {
+		fmt.Printf("Обновление несуществующего: %v\n", err)
+	}
+
+	// Чтение одного: gorm.ErrRecordNotFound -> ErrUserNotFound
+	if _, err := repo.GetByID(ctx, 999999); errors.Is(err, ErrUserNotFound) {
+		fmt.Printf("Поиск несуществующего: %v\n", err)
+	}
 
 	// Чтение
-	var users []User
-	db.Debug().Find(&users)
+	users, err := repo.List(ctx)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
 	for _, u := range users {
-		fmt.Printf("ID: %d, Имя: %s, Возраст: %d\n", u.ID, u.Name, u.Age)
+		e := "-"
+		if u.Email != nil {
+			e = *u.Email
+		}
+		fmt.Printf("ID: %d, Имя: %s, Возраст: %d, Email: %s\n", u.ID, u.Name, u.Age, e)
 	}
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Доменные ошибки: вызывающий код проверяет их через errors.Is и не зависит от GORM
+var (
+	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrUserExists   = errors.New("пользователь с таким email уже существует")
+)
+
+// UserRepository — слой доступа к данным поверх GORM.
+// Каждый метод проверяет result.Error, а изменения — ещё и RowsAffected.
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *User) error {
+	result := r.db.WithContext(ctx).Create(u)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка создания пользователя %s: %w", u.Name, mapError(result.Error))
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*User, error) {
+	var u User
+	if err := r.db.WithContext(ctx).First(&u, id).Error; err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователя id=%d: %w", id, mapError(err))
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	var u User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователя %s: %w", email, mapError(err))
+	}
+	return &u, nil
+}
+
+// List возвращает пустой срез, а не ErrUserNotFound: Find не считает пустой результат ошибкой
+func (r *UserRepository) List(ctx context.Context) ([]User, error) {
+	var users []User
+	if err := r.db.WithContext(ctx).Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("ошибка чтения пользователей: %w", err)
+	}
+	return users, nil
+}
+
+// UpdateAge — UPDATE без найденной строки не ошибка для базы, поэтому смотрим на RowsAffected
+func (r *UserRepository) UpdateAge(ctx context.Context, id uint, age int) error {
+	result := r.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Update("age", age)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка обновления пользователя id=%d: %w", id, mapError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("обновление пользователя id=%d: %w", id, ErrUserNotFound)
+	}
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&User{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка удаления пользователя id=%d: %w", id, mapError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("удаление пользователя id=%d: %w", id, ErrUserNotFound)
+	}
+	return nil
+}
+
+// mapError переводит ошибки GORM в доменные.
+// gorm.ErrDuplicatedKey приходит только при gorm.Config{TranslateError: true}.
+func mapError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrUserExists
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Account — строка accounts из 11_pgx_conn_pool_migration_goose
+type Account struct {
+	ID        int       `db:"id"`
+	Username  string    `db:"username"`
+	Email     string    `db:"email"`
+	IsActive  bool      `db:"is_active"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AccountRepository — тот же набор приёмов, что у UserRepository, для таблицы accounts
+type AccountRepository struct {
+	db *sqlx.DB
+
+	insert     *sqlx.NamedStmt
+	byUsername *sqlx.NamedStmt
+	deactivate *sqlx.NamedStmt
+}
+
+func NewAccountRepository(ctx context.Context, db *sqlx.DB) (*AccountRepository, error) {
+	r := &AccountRepository{db: db}
+
+	var err error
+	if r.insert, err = db.PrepareNamedContext(ctx, `
+		INSERT INTO accounts (username, email) VALUES (:username, :email)
+		RETURNING id, is_active, created_at`); err != nil {
+		return nil, fmt.Errorf("ошибка подготовки insert: %w", err)
+	}
+	if r.byUsername, err = db.PrepareNamedContext(ctx, `
+		SELECT id, username, email, is_active, created_at
+		FROM accounts WHERE username = :username`); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("ошибка подготовки byUsername: %w", err)
+	}
+	if r.deactivate, err = db.PrepareNamedContext(ctx,
+		`UPDATE accounts SET is_active = false WHERE id = :id AND is_active`); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("ошибка подготовки deactivate: %w", err)
+	}
+	return r, nil
+}
+
+func (r *AccountRepository) Close() error {
+	return closeAll(r.insert, r.byUsername, r.deactivate)
+}
+
+// Create заполняет ID, IsActive и CreatedAt значениями, которые поставила база
+func (r *AccountRepository) Create(ctx context.Context, a *Account) error {
+	if err := r.insert.QueryRowxContext(ctx, a).Scan(&a.ID, &a.IsActive, &a.CreatedAt); err != nil {
+		return fmt.Errorf("ошибка создания аккаунта %s: %w", a.Username, err)
+	}
+	return nil
+}
+
+func (r *AccountRepository) GetByUsername(ctx context.Context, username string) (*Account, error) {
+	var a Account
+	if err := r.byUsername.GetContext(ctx, &a, map[string]any{"username": username}); err != nil {
+		return nil, fmt.Errorf("аккаунт %s: %w", username, notFound(err))
+	}
+	return &a, nil
+}
+
+func (r *AccountRepository) GetByIDs(ctx context.Context, ids []int) ([]Account, error) {
+	accounts, err := selectByIDs[Account](ctx, r.db, `
+		SELECT id, username, email, is_active, created_at
+		FROM accounts WHERE id IN (?) ORDER BY id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка пакетного поиска аккаунтов: %w", err)
+	}
+	return accounts, nil
+}
+
+// CreateBatch пропускает уже существующие username и email; возвращает число вставленных строк
+func (r *AccountRepository) CreateBatch(ctx context.Context, accounts []Account) (int64, error) {
+	n, err := namedExecBatches(ctx, r.db, `
+		INSERT INTO accounts (username, email) VALUES (:username, :email)
+		ON CONFLICT DO NOTHING`, accounts)
+	if err != nil {
+		return n, fmt.Errorf("ошибка пакетной вставки аккаунтов: %w", err)
+	}
+	return n, nil
+}
+
+// Deactivate — мягкое удаление; ErrNotFound, если аккаунта нет или он уже неактивен
+func (r *AccountRepository) Deactivate(ctx context.Context, id int) error {
+	res, err := r.deactivate.ExecContext(ctx, map[string]any{"id": id})
+	if err != nil {
+		return fmt.Errorf("ошибка деактивации аккаунта id=%d: %w", id, err)
+	}
+	if err := expectOne(res); err != nil {
+		return fmt.Errorf("аккаунт id=%d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNotFound — запись не найдена; репозитории оборачивают его с контекстом
+var ErrNotFound = errors.New("запись не найдена")
+
+// batchSize — сколько строк вставляет один NamedExec со срезом:
+// каждая строка добавляет параметры, а у SQLite и PostgreSQL есть предел их числа в запросе
+const batchSize = 500
+
+// notFound переводит sql.ErrNoRows от Get в ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// selectByIDs — SELECT ... WHERE id IN (?) через sqlx.In и Rebind под драйвер базы
+func selectByIDs[T any](ctx context.Context, db *sqlx.DB, query string, ids []int) ([]T, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	q, args, err := sqlx.In(query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка раскрытия IN: %w", err)
+	}
+
+	var out []T
+	if err := db.SelectContext(ctx, &out, db.Rebind(q), args...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// namedExecBatches выполняет многострочный INSERT из среза структур порциями по batchSize
+func namedExecBatches[T any](ctx context.Context, db *sqlx.DB, query string, items []T) (int64, error) {
+	var total int64
+	for start := 0; start < len(items); start += batchSize {
+		end := min(start+batchSize, len(items))
+		res, err := db.NamedExecContext(ctx, query, items[start:end])
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// expectOne проверяет, что UPDATE или DELETE затронул строку
+func expectOne(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// closeAll закрывает подготовленные запросы, возвращает первую ошибку
+func closeAll(stmts ...*sqlx.NamedStmt) error {
+	var first error
+	for _, s := range stmts {
+		if s == nil {
+			continue
+		}
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type User struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+// UserRepository — доступ к таблице users.
+// Частые запросы подготавливаются один раз в конструкторе через PrepareNamed.
+type UserRepository struct {
+	db *sqlx.DB
+
+	insert    *sqlx.NamedStmt
+	byID      *sqlx.NamedStmt
+	updateAge *sqlx.NamedStmt
+}
+
+func NewUserRepository(ctx context.Context, db *sqlx.DB) (*UserRepository, error) {
+	r := &UserRepository{db: db}
+
+	// :name превращаются в ? или $1 по драйверу — тот же текст работает в SQLite и PostgreSQL
+	var err error
+	if r.insert, err = db.PrepareNamedContext(ctx,
+		`INSERT INTO users (name, age) VALUES (:name, :age) RETURNING id`); err != nil {
+		return nil, fmt.Errorf("ошибка подготовки insert: %w", err)
+	}
+	if r.byID, err = db.PrepareNamedContext(ctx,
+		`SELECT id, name, age FROM users WHERE id = :id`); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("ошибка подготовки byID: %w", err)
+	}
+	if r.updateAge, err = db.PrepareNamedContext(ctx,
+		`UPDATE users SET age = :age WHERE id = :id`); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("ошибка подготовки updateAge: %w", err)
+	}
+	return r, nil
+}
+
+// Close освобождает подготовленные запросы
+func (r *UserRepository) Close() error {
+	return closeAll(r.insert, r.byID, r.updateAge)
+}
+
+// Create вставляет пользователя и записывает сгенерированный id в u.ID
+func (r *UserRepository) Create(ctx context.Context, u *User) error {
+	if err := r.insert.GetContext(ctx, &u.ID, u); err != nil {
+		return fmt.Errorf("ошибка создания пользователя %s: %w", u.Name, err)
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*User, error) {
+	var u User
+	if err := r.byID.GetContext(ctx, &u, map[string]any{"id": id}); err != nil {
+		return nil, fmt.Errorf("пользователь id=%d: %w", id, notFound(err))
+	}
+	return &u, nil
+}
+
+// GetByIDs — пакетный поиск; отсутствующие id просто не попадают в результат
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []int) ([]User, error) {
+	users, err := selectByIDs[User](ctx, r.db, `SELECT id, name, age FROM users WHERE id IN (?) ORDER BY id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка пакетного поиска пользователей: %w", err)
+	}
+	return users, nil
+}
+
+// CreateBatch — многострочный INSERT ... VALUES (...), (...) из среза структур
+func (r *UserRepository) CreateBatch(ctx context.Context, users []User) (int64, error) {
+	n, err := namedExecBatches(ctx, r.db, `INSERT INTO users (name, age) VALUES (:name, :age)`, users)
+	if err != nil {
+		return n, fmt.Errorf("ошибка пакетной вставки пользователей: %w", err)
+	}
+	return n, nil
+}
+
+func (r *UserRepository) UpdateAge(ctx context.Context, id, age int) error {
+	res, err := r.updateAge.ExecContext(ctx, map[string]any{"id": id, "age": age})
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пользователя id=%d: %w", id, err)
+	}
+	if err := expectOne(res); err != nil {
+		return fmt.Errorf("пользователь id=%d: %w", id, err)
+	}
+	return nil
+}
+
+// ListOlderThan показывает Rebind для обычного запроса с ?
+func (r *UserRepository) ListOlderThan(ctx context.Context, age int) ([]User, error) {
+	var users []User
+	err := r.db.SelectContext(ctx, &users, r.db.Rebind(`SELECT id, name, age FROM users WHERE age > ? ORDER BY id`), age)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения пользователей: %w", err)
+	}
+	return users, nil
+}
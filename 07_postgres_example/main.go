@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -17,44 +22,57 @@ const (
 )
 
 func main() {
+	// Ctrl+C отменяет контекст: lib/pq отправит серверу CancelRequest для текущего запроса
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// log.Fatal не выполняет defer, поэтому вся работа — в run, а db.Close успевает отработать
+	if err := run(ctx); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+func run(ctx context.Context) error {
 	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
 
 	db, err := sql.Open("postgres", psqlInfo)
 	if err != nil {
-		log.Fatalf("Ошибка при открытии подключения: %v\n", err)
+		return fmt.Errorf("ошибка при открытии подключения: %w", err)
 	}
 	defer db.Close()
 
 	// Проверка подключения
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Не удалось подключиться к базе данных: %v\n", err)
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err = db.PingContext(pingCtx); err != nil {
+		return fmt.Errorf("не удалось подключиться к базе данных: %w", err)
 	}
 	fmt.Println("Успешное подключение к PostgreSQL!")
 
 	// Создание таблицы
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS users (
 		id SERIAL PRIMARY KEY,
 		name TEXT NOT NULL,
 		email TEXT UNIQUE NOT NULL
 	)`)
 	if err != nil {
-		log.Fatalf("Ошибка при создании таблицы: %v\n", err)
+		return fmt.Errorf("ошибка при создании таблицы: %w", err)
 	}
 	fmt.Println("Таблица 'users' создана или уже существует.")
 
 	// Вставка данных
-	_, err = db.Exec("INSERT INTO users (name, email) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+	_, err = db.ExecContext(ctx, "INSERT INTO users (name, email) VALUES ($1, $2) ON CONFLICT DO NOTHING",
 		"Alexey Kozadaev", "akozadaev@inbox.ru")
 	if err != nil {
-		log.Fatalf("Ошибка при вставке данных: %v\n", err)
+		return fmt.Errorf("ошибка при вставке данных: %w", err)
 	}
 	fmt.Println("Данные вставлены.")
 
 	// Выборка данных
-	rows, err := db.Query("SELECT id, name, email FROM users")
+	rows, err := db.QueryContext(ctx, "SELECT id, name, email FROM users")
 	if err != nil {
-		log.Fatalf("Ошибка при выполнении SELECT: %v\n", err)
+		return fmt.Errorf("ошибка при выполнении SELECT: %w", err)
 	}
 	defer rows.Close()
 
@@ -63,12 +81,13 @@ func main() {
 		var id int
 		var name, email string
 		if err := rows.Scan(&id, &name, &email); err != nil {
-			log.Fatalf("Ошибка при чтении строки: %v\n", err)
+			return fmt.Errorf("ошибка при чтении строки: %w", err)
 		}
 		fmt.Printf("ID: %d | Имя: %s | Email: %s\n", id, name, email)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Fatalf("Ошибка при итерации по результатам: %v\n", err)
+		return fmt.Errorf("ошибка при итерации по результатам: %w", err)
 	}
+	return nil
 }
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -17,13 +21,32 @@ const (
 )
 
 func main() {
+	// Ctrl+C отменяет контекст, и pgx прерывает текущий запрос
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// log.Fatal не выполняет defer, поэтому вся работа — в run, а соединение успевает закрыться
+	if err := run(ctx); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println("\n Пример завершён успешно!")
+}
+
+func run(ctx context.Context) error {
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", user, password, host, port, dbname)
 
-	conn, err := pgx.Connect(context.Background(), connStr)
+	connectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(connectCtx, connStr)
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к базе данных: %v\n", err)
+		return fmt.Errorf("не удалось подключиться к базе данных: %w", err)
 	}
-	defer conn.Close(context.Background())
+	defer func() {
+		// Close отправляет серверу Terminate; после Ctrl+C ctx уже отменён, поэтому свой контекст
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		conn.Close(closeCtx)
+	}()
 
 	fmt.Println("Успешное подключение к PostgreSQL!")
 
@@ -34,27 +57,27 @@ func main() {
 			email TEXT UNIQUE NOT NULL
 		);`
 
-	_, err = conn.Exec(context.Background(), createTableSQL)
+	_, err = conn.Exec(ctx, createTableSQL)
 	if err != nil {
-		log.Fatalf("Ошибка при создании таблицы: %v\n", err)
+		return fmt.Errorf("ошибка при создании таблицы: %w", err)
 	}
 	fmt.Println("Таблица 'users' создана или уже существует.")
 
 	insertSQL := "INSERT INTO users (name, email) VALUES ($1, $2) ON CONFLICT DO NOTHING;"
-	_, err = conn.Exec(context.Background(), insertSQL, "Иван Иванов", "ivan@example.com")
+	_, err = conn.Exec(ctx, insertSQL, "Иван Иванов", "ivan@example.com")
 	if err != nil {
-		log.Fatalf("Ошибка при вставке данных: %v\n", err)
+		return fmt.Errorf("ошибка при вставке данных: %w", err)
 	}
-	_, err = conn.Exec(context.Background(), insertSQL, "Мария Машина", "maria@example.com")
+	_, err = conn.Exec(ctx, insertSQL, "Мария Машина", "maria@example.com")
 	if err != nil {
-		log.Fatalf("Ошибка при вставке данных: %v\n", err)
+		return fmt.Errorf("ошибка при вставке данных: %w", err)
 	}
 
 	fmt.Println("Данные успешно вставлены.")
 
-	rows, err := conn.Query(context.Background(), "SELECT id, name, email FROM users;")
+	rows, err := conn.Query(ctx, "SELECT id, name, email FROM users;")
 	if err != nil {
-		log.Fatalf("Ошибка при выполнении запроса: %v\n", err)
+		return fmt.Errorf("ошибка при выполнении запроса: %w", err)
 	}
 	defer rows.Close()
 
@@ -64,14 +87,13 @@ func main() {
 		var name, email string
 		err = rows.Scan(&id, &name, &email)
 		if err != nil {
-			log.Fatalf("Ошибка при чтении строки: %v\n", err)
+			return fmt.Errorf("ошибка при чтении строки: %w", err)
 		}
 		fmt.Printf("ID: %d, Имя: %s, Email: %s\n", id, name, email)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Fatalf("Ошибка при итерации по строкам: %v\n", err)
+		return fmt.Errorf("ошибка при итерации по строкам: %w", err)
 	}
-
-	fmt.Println("\n Пример завершён успешно!")
+	return nil
 }
@@ -0,0 +1,33 @@
+// Package account — проверка полей аккаунта урока 10. Её же используют GORM (17_gorm_rbac),
+// REST (30_rest_api) и gRPC (31_grpc), чтобы правила и тексты ошибок не расходились.
+package account
+
+import (
+	"errors"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Account — поля нового или изменённого аккаунта, которые проверяются до запроса к базе
+type Account struct {
+	Username string
+	Email    string
+}
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// Validate — те же правила, что в CHECK таблицы accounts (001_init_tables.sql).
+// Длина считается в символах, как LENGTH и VARCHAR(50) в PostgreSQL.
+func (a *Account) Validate() error {
+	n := utf8.RuneCountInString(a.Username)
+	if n < 3 {
+		return errors.New("username должен быть не короче 3 символов")
+	}
+	if n > 50 {
+		return errors.New("username длиннее 50 символов")
+	}
+	if !emailRegex.MatchString(a.Email) {
+		return errors.New("некорректный формат email")
+	}
+	return nil
+}
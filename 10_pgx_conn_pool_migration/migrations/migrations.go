@@ -0,0 +1,8 @@
+// Package migrations — начальная схема урока, встроенная строкой, чтобы её
+// могли применять и другие уроки (gRPC).
+package migrations
+
+import _ "embed"
+
+//go:embed 001_init_tables.sql
+var InitSQL string
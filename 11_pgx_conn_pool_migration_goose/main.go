@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"regexp"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -44,19 +47,27 @@ func (a *Account) Validate() error {
 }
 
 func main() {
-	ctx := context.Background()
+	// Ctrl+C отменяет контекст: миграция или транзакция прерывается, пул закрывается в run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	if err := run(ctx); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println("\nГотово!")
+}
+
+func run(ctx context.Context) error {
 	// 1. Создаём пул для приложения
 	pool, err := pgxpool.New(ctx, fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", user, password, host, port, dbname))
 	if err != nil {
-		log.Fatalf("❌ Не удалось создать пул: %v", err)
+		return fmt.Errorf("не удалось создать пул: %w", err)
 	}
 	defer pool.Close()
 
 	// 2. Применяем миграции через goose
-	err = applyMigrationsWithGoose()
-	if err != nil {
-		log.Fatalf("❌ Ошибка миграций: %v", err)
+	if err := applyMigrationsWithGoose(ctx); err != nil {
+		return fmt.Errorf("ошибка миграций: %w", err)
 	}
 
 	// 3. Создаём аккаунты в транзакции
@@ -65,18 +76,17 @@ func main() {
 		{"bob", "bob@example.com"},
 	}
 
-	err = createAccountsInTransaction(ctx, pool, accounts)
-	if err != nil {
-		log.Fatalf("❌ Ошибка создания аккаунтов: %v", err)
+	if err := createAccountsInTransaction(ctx, pool, accounts); err != nil {
+		return fmt.Errorf("ошибка создания аккаунтов: %w", err)
 	}
 
 	// 4. Выводим данные
 	printAccountsAndRoles(ctx, pool)
-	fmt.Println("\nГотово!")
+	return nil
 }
 
 // Применение миграций через goose
-func applyMigrationsWithGoose() error {
+func applyMigrationsWithGoose(ctx context.Context) error {
 	// Goose работает через стандартный database/sql, поэтому нужен stdlib-адаптер
 	config, err := pgx.ParseConfig(fmt.Sprintf("host=%s port=%d user=%s password=%s database=%s sslmode=disable",
 		host, port, user, password, dbname))
@@ -89,8 +99,8 @@ func applyMigrationsWithGoose() error {
 	defer db.Close()
 
 	// Применяем все миграции вверх
-	if err = goose.Up(db, "migrations"); err != nil {
-		return fmt.Errorf("goose.Up failed: %w", err)
+	if err = goose.UpContext(ctx, db, "migrations"); err != nil {
+		return fmt.Errorf("goose.UpContext failed: %w", err)
 	}
 
 	fmt.Println("Миграции применены через goose")
@@ -182,4 +192,7 @@ func printAccountsAndRoles(ctx context.Context, pool *pgxpool.Pool) {
 		_ = rows.Scan(&username, &email, &role, &expires)
 		fmt.Printf("  %s (%s)  %s, сессия до %s\n", username, email, role, expires.Format("2006-01-02"))
 	}
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠️ Ошибка запроса: %v", err)
+	}
 }
@@ -0,0 +1,64 @@
+// Package migrations — схема аккаунтов (001–003), общая для уроков, которые
+// строятся поверх неё. goose при запуске из каталога этот файл пропускает:
+// у него нет номера версии в имени.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// With накладывает миграции урока поверх общей схемы. Результат подходит
+// для goose.NewProvider: при совпадении имён побеждает файл из extra.
+func With(extra ...fs.FS) fs.FS {
+	return layers(append([]fs.FS{FS}, extra...))
+}
+
+// layers — объединение нескольких fs.FS; последний слой перекрывает предыдущие.
+type layers []fs.FS
+
+func (l layers) Open(name string) (fs.File, error) {
+	for i := len(l) - 1; i >= 0; i-- {
+		f, err := l[i].Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir объединяет содержимое каталога из всех слоёв (нужно для fs.Glob).
+func (l layers) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := make(map[string]fs.DirEntry)
+	found := false
+	for _, layer := range l {
+		entries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, e := range entries {
+			seen[e.Name()] = e
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	out := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
@@ -0,0 +1,140 @@
+package rowscan
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// field описывает одно поле структуры, в которое сканируется колонка
+type field struct {
+	column   string
+	index    []int
+	path     string
+	optional bool
+}
+
+// structMap — сопоставление колонок с полями для конкретного типа
+type structMap struct {
+	fields  []field
+	byName  map[string]int
+	typName string
+}
+
+var structMaps sync.Map // reflect.Type -> *structMap
+
+// mapStruct строит (и кэширует) карту полей типа t.
+// Имя колонки берётся из тега db, без тега — имя поля в нижнем регистре, как в sqlx.
+// Тег db:"-" исключает поле, db:"name,optional" разрешает отсутствие колонки в результате.
+func mapStruct(t reflect.Type) (*structMap, error) {
+	if m, ok := structMaps.Load(t); ok {
+		return m.(*structMap), nil
+	}
+
+	m := &structMap{byName: make(map[string]int), typName: t.String()}
+	if err := m.walk(t, nil, ""); err != nil {
+		return nil, err
+	}
+
+	actual, _ := structMaps.LoadOrStore(t, m)
+	return actual.(*structMap), nil
+}
+
+func (m *structMap) walk(t reflect.Type, parent []int, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		// Встроенная структура без тега — её поля поднимаются на верхний уровень
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if !f.IsExported() {
+					return fmt.Errorf("rowscan: встроенная структура %s в %s должна быть экспортируемой", f.Name, m.typName)
+				}
+				if err := m.walk(ft, index, prefix+f.Name+"."); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		if prev, ok := m.byName[name]; ok {
+			return fmt.Errorf("rowscan: колонка %q сопоставлена сразу двум полям %s: %s и %s",
+				name, m.typName, m.fields[prev].path, prefix+f.Name)
+		}
+		m.byName[name] = len(m.fields)
+		m.fields = append(m.fields, field{
+			column:   name,
+			index:    index,
+			path:     prefix + f.Name,
+			optional: opts == "optional",
+		})
+	}
+	return nil
+}
+
+// plan сопоставляет список колонок результата с полями и проверяет,
+// что нет ни лишних колонок, ни обязательных полей без колонки
+func (m *structMap) plan(columns []string) ([]int, error) {
+	plan := make([]int, len(columns))
+	seen := make([]bool, len(m.fields))
+
+	for i, col := range columns {
+		idx, ok := m.byName[col]
+		if !ok {
+			return nil, fmt.Errorf("rowscan: лишняя колонка %q — в %s нет подходящего поля", col, m.typName)
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("rowscan: колонка %q встречается в результате несколько раз", col)
+		}
+		seen[idx] = true
+		plan[i] = idx
+	}
+
+	var missing []string
+	for i, f := range m.fields {
+		if !seen[i] && !f.optional {
+			missing = append(missing, fmt.Sprintf("%s (колонка %q)", f.path, f.column))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("rowscan: в результате нет колонок для полей %s: %s",
+			m.typName, strings.Join(missing, ", "))
+	}
+
+	return plan, nil
+}
+
+// fieldAddr возвращает указатель на поле, создавая встроенные структуры-указатели по пути
+func fieldAddr(v reflect.Value, index []int) any {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v.Addr().Interface()
+}
@@ -0,0 +1,235 @@
+// Package rowscan сканирует строки результата в структуры по тегу db
+// (как sqlx в 06_sqlx_queries) и одинаково работает с pgx и database/sql.
+//
+// NULL поддерживается через поля-указатели (*string, *time.Time) и типы sql.Null*.
+// Встроенные структуры (в том числе по указателю) разворачиваются в колонки верхнего уровня.
+package rowscan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrNoRows возвращается QueryOne, если запрос не вернул ни одной строки
+	ErrNoRows = errors.New("rowscan: запрос не вернул строк")
+	// ErrTooManyRows возвращается QueryOne, если строк больше одной
+	ErrTooManyRows = errors.New("rowscan: запрос вернул больше одной строки")
+)
+
+// Rows — общий интерфейс результата. *sql.Rows реализует его напрямую,
+// pgx.Rows оборачивается через FromPgx.
+type Rows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
+// FromPgx адаптирует pgx.Rows к интерфейсу Rows
+func FromPgx(rows pgx.Rows) Rows {
+	return pgxRows{rows}
+}
+
+type pgxRows struct {
+	pgx.Rows
+}
+
+func (r pgxRows) Columns() ([]string, error) {
+	fields := r.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+	return columns, nil
+}
+
+func (r pgxRows) Close() error {
+	r.Rows.Close()
+	return nil
+}
+
+// Querier выполняет запрос и возвращает строки результата
+type Querier interface {
+	QueryRows(ctx context.Context, query string, args ...any) (Rows, error)
+}
+
+// PgxQuerier — *pgx.Conn, *pgxpool.Pool или pgx.Tx
+type PgxQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// SQLQuerier — *sql.DB, *sql.Tx или *sql.Conn
+type SQLQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Pgx оборачивает соединение, пул или транзакцию pgx
+func Pgx(q PgxQuerier) Querier {
+	return pgxQuerier{q}
+}
+
+// SQL оборачивает *sql.DB, *sql.Tx или *sql.Conn
+func SQL(q SQLQuerier) Querier {
+	return sqlQuerier{q}
+}
+
+type pgxQuerier struct {
+	q PgxQuerier
+}
+
+func (p pgxQuerier) QueryRows(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := p.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return FromPgx(rows), nil
+}
+
+type sqlQuerier struct {
+	q SQLQuerier
+}
+
+func (s sqlQuerier) QueryRows(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Scanner сканирует строки с фиксированным набором колонок в значения T.
+// План сопоставления строится один раз, поэтому Scanner выгодно переиспользовать.
+type Scanner[T any] struct {
+	m    *structMap
+	plan []int
+}
+
+// NewScanner проверяет, что колонки точно соответствуют полям T
+func NewScanner[T any](columns []string) (*Scanner[T], error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("rowscan: ожидалась структура, получен %s", t)
+	}
+
+	m, err := mapStruct(t)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := m.plan(columns)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner[T]{m: m, plan: plan}, nil
+}
+
+// Scan читает текущую строку в новое значение T
+func (s *Scanner[T]) Scan(row interface{ Scan(dest ...any) error }) (T, error) {
+	var out T
+	v := reflect.ValueOf(&out).Elem()
+
+	dest := make([]any, len(s.plan))
+	for i, idx := range s.plan {
+		dest[i] = fieldAddr(v, s.m.fields[idx].index)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return out, fmt.Errorf("rowscan: ошибка сканирования в %s: %w", s.m.typName, err)
+	}
+	return out, nil
+}
+
+// ScanEach вызывает fn для каждой строки и закрывает rows.
+// Ошибка из fn прерывает чтение и возвращается как есть.
+func ScanEach[T any](rows Rows, fn func(T) error) error {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	scanner, err := NewScanner[T](columns)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		item, err := scanner.Scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ScanAll читает все строки в срез
+func ScanAll[T any](rows Rows) ([]T, error) {
+	var items []T
+	err := ScanEach(rows, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ScanOne читает ровно одну строку
+func ScanOne[T any](rows Rows) (T, error) {
+	var (
+		out   T
+		found bool
+	)
+	err := ScanEach(rows, func(item T) error {
+		if found {
+			return ErrTooManyRows
+		}
+		out, found = item, true
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !found {
+		return out, ErrNoRows
+	}
+	return out, nil
+}
+
+// QueryAll выполняет запрос и возвращает все строки
+func QueryAll[T any](ctx context.Context, q Querier, query string, args ...any) ([]T, error) {
+	rows, err := q.QueryRows(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ScanAll[T](rows)
+}
+
+// QueryOne выполняет запрос, который должен вернуть ровно одну строку
+func QueryOne[T any](ctx context.Context, q Querier, query string, args ...any) (T, error) {
+	rows, err := q.QueryRows(ctx, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return ScanOne[T](rows)
+}
+
+// QueryEach выполняет запрос и передаёт строки в fn по одной, не накапливая их в памяти
+func QueryEach[T any](ctx context.Context, q Querier, fn func(T) error, query string, args ...any) error {
+	rows, err := q.QueryRows(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return ScanEach(rows, fn)
+}
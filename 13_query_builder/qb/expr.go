@@ -0,0 +1,273 @@
+package qb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Expr — фрагмент SQL, который умеет записать себя в буфер вместе с аргументами.
+// Значение типа Expr, переданное как аргумент (например, в Set), вставляется как SQL, а не биндится.
+type Expr interface {
+	writeTo(b *buffer)
+}
+
+type rawExpr struct {
+	sql  string
+	args []any
+}
+
+// Raw — произвольный фрагмент SQL с плейсхолдерами ? (литерал ? записывается как ??).
+// Плейсхолдеры переписываются под диалект при рендеринге.
+func Raw(sql string, args ...any) Expr {
+	return rawExpr{sql: sql, args: args}
+}
+
+func (e rawExpr) writeTo(b *buffer) {
+	b.writeRaw(e.sql, e.args)
+}
+
+type cmpExpr struct {
+	column string
+	op     string
+	value  any
+}
+
+func (e cmpExpr) writeTo(b *buffer) {
+	b.write(e.column, " ", e.op, " ")
+	b.arg(e.value)
+}
+
+// Eq — column = value
+func Eq(column string, value any) Expr { return cmpExpr{column, "=", value} }
+
+// NotEq — column <> value
+func NotEq(column string, value any) Expr { return cmpExpr{column, "<>", value} }
+
+// Gt — column > value
+func Gt(column string, value any) Expr { return cmpExpr{column, ">", value} }
+
+// Gte — column >= value
+func Gte(column string, value any) Expr { return cmpExpr{column, ">=", value} }
+
+// Lt — column < value
+func Lt(column string, value any) Expr { return cmpExpr{column, "<", value} }
+
+// Lte — column <= value
+func Lte(column string, value any) Expr { return cmpExpr{column, "<=", value} }
+
+type likeExpr struct {
+	column  string
+	pattern string
+}
+
+func (e likeExpr) writeTo(b *buffer) {
+	b.write(e.column, " LIKE ")
+	b.arg(e.pattern)
+	b.write(` ESCAPE '\'`)
+}
+
+// Prefix — column LIKE 'prefix%'; символы %, _ и \ в prefix экранируются
+func Prefix(column, prefix string) Expr {
+	return likeExpr{column, escapeLike(prefix) + "%"}
+}
+
+// Contains — column LIKE '%substr%' с экранированием
+func Contains(column, substr string) Expr {
+	return likeExpr{column, "%" + escapeLike(substr) + "%"}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+type inExpr struct {
+	column string
+	values []any
+	not    bool
+}
+
+// In — column IN (...). values может быть срезом любого типа — он разворачивается
+// в отдельные плейсхолдеры. Пустой список даёт заведомо ложное условие.
+func In(column string, values any) Expr {
+	return inExpr{column: column, values: expand(values)}
+}
+
+// NotIn — column NOT IN (...); для пустого списка условие всегда истинно
+func NotIn(column string, values any) Expr {
+	return inExpr{column: column, values: expand(values), not: true}
+}
+
+func (e inExpr) writeTo(b *buffer) {
+	if len(e.values) == 0 {
+		if e.not {
+			b.write("1 = 1")
+		} else {
+			b.write("1 = 0")
+		}
+		return
+	}
+
+	b.write(e.column)
+	if e.not {
+		b.write(" NOT")
+	}
+	b.write(" IN (")
+	for i, v := range e.values {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.arg(v)
+	}
+	b.write(")")
+}
+
+// expand превращает срез в []any; []byte и не-срезы считаются одним значением
+func expand(values any) []any {
+	if vs, ok := values.([]any); ok {
+		return vs
+	}
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{values}
+	}
+	out := make([]any, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+	return out
+}
+
+type nullExpr struct {
+	column string
+	not    bool
+}
+
+func (e nullExpr) writeTo(b *buffer) {
+	if e.not {
+		b.write(e.column, " IS NOT NULL")
+	} else {
+		b.write(e.column, " IS NULL")
+	}
+}
+
+// IsNull — column IS NULL
+func IsNull(column string) Expr { return nullExpr{column: column} }
+
+// IsNotNull — column IS NOT NULL
+func IsNotNull(column string) Expr { return nullExpr{column: column, not: true} }
+
+type listExpr struct {
+	op    string
+	exprs []Expr
+}
+
+// And объединяет условия через AND
+func And(exprs ...Expr) Expr { return listExpr{"AND", exprs} }
+
+// Or объединяет условия через OR
+func Or(exprs ...Expr) Expr { return listExpr{"OR", exprs} }
+
+func (e listExpr) writeTo(b *buffer) {
+	switch len(e.exprs) {
+	case 0:
+		// Пустой AND — истина, пустой OR — ложь
+		if e.op == "AND" {
+			b.write("1 = 1")
+		} else {
+			b.write("1 = 0")
+		}
+		return
+	case 1:
+		e.exprs[0].writeTo(b)
+		return
+	}
+
+	b.write("(")
+	for i, x := range e.exprs {
+		if i > 0 {
+			b.write(" ", e.op, " ")
+		}
+		x.writeTo(b)
+	}
+	b.write(")")
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+// Not — NOT (expr)
+func Not(expr Expr) Expr { return notExpr{expr} }
+
+func (e notExpr) writeTo(b *buffer) {
+	b.write("NOT (")
+	e.expr.writeTo(b)
+	b.write(")")
+}
+
+// buffer накапливает текст запроса и аргументы в порядке их появления
+type buffer struct {
+	d    Dialect
+	sb   strings.Builder
+	args []any
+	err  error
+}
+
+func (b *buffer) write(parts ...string) {
+	for _, p := range parts {
+		b.sb.WriteString(p)
+	}
+}
+
+func (b *buffer) arg(v any) {
+	if e, ok := v.(Expr); ok {
+		e.writeTo(b)
+		return
+	}
+	b.args = append(b.args, v)
+	b.sb.WriteString(b.d.placeholder(len(b.args)))
+}
+
+// writeRaw копирует фрагмент, заменяя ? на плейсхолдеры диалекта.
+// Знаки вопроса внутри строковых литералов и идентификаторов в кавычках не трогаются.
+func (b *buffer) writeRaw(sql string, args []any) {
+	n := 0
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b.sb.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			b.sb.WriteByte(c)
+		case c == '?' && i+1 < len(sql) && sql[i+1] == '?':
+			b.sb.WriteByte('?')
+			i++
+		case c == '?':
+			if n >= len(args) {
+				b.fail(fmt.Errorf("qb: в %q плейсхолдеров больше, чем аргументов (%d)", sql, len(args)))
+				return
+			}
+			b.arg(args[n])
+			n++
+		default:
+			b.sb.WriteByte(c)
+		}
+	}
+	if n != len(args) {
+		b.fail(fmt.Errorf("qb: в %q %d плейсхолдеров, а аргументов %d", sql, n, len(args)))
+	}
+}
+
+func (b *buffer) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,436 @@
+// Package qb — построитель SELECT/INSERT/UPDATE/DELETE для динамических фильтров.
+//
+// Все значения передаются только через плейсхолдеры, списки для IN разворачиваются
+// в отдельные параметры, а сортировка из пользовательского ввода проходит через белый список.
+// Один и тот же запрос рендерится в ? (SQLite) или $n (PostgreSQL).
+package qb
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dialect определяет формат плейсхолдеров
+type Dialect int
+
+const (
+	// Question — плейсхолдеры ? (SQLite, MySQL)
+	Question Dialect = iota
+	// Dollar — плейсхолдеры $1, $2, ... (PostgreSQL)
+	Dollar
+)
+
+func (d Dialect) placeholder(n int) string {
+	if d == Dollar {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+var (
+	// ErrSortNotAllowed — поле сортировки отсутствует в белом списке
+	ErrSortNotAllowed = errors.New("qb: сортировка по этому полю не разрешена")
+	// ErrMissingWhere — UPDATE или DELETE без условия
+	ErrMissingWhere = errors.New("qb: UPDATE/DELETE без WHERE запрещён, используйте Where(qb.Raw(\"1 = 1\")) явно")
+)
+
+// SelectBuilder строит SELECT-запрос
+type SelectBuilder struct {
+	columns  []string
+	from     string
+	joins    []Expr
+	where    []Expr
+	groupBy  []string
+	having   []Expr
+	orderBy  []string
+	sortable map[string]string
+	sortSpec string
+	limit    *int
+	offset   *int
+}
+
+// Select начинает SELECT с перечисленными колонками
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// Clone возвращает независимую копию — удобно, когда от общего запроса
+// с фильтрами строятся несколько вариантов (страница, COUNT и т.п.)
+func (s *SelectBuilder) Clone() *SelectBuilder {
+	c := *s
+	c.columns = slices.Clone(s.columns)
+	c.joins = slices.Clone(s.joins)
+	c.where = slices.Clone(s.where)
+	c.groupBy = slices.Clone(s.groupBy)
+	c.having = slices.Clone(s.having)
+	c.orderBy = slices.Clone(s.orderBy)
+	return &c
+}
+
+// From задаёт таблицу (можно с псевдонимом: "accounts a")
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.from = table
+	return s
+}
+
+// Join добавляет INNER JOIN; в on допускаются плейсхолдеры ?
+func (s *SelectBuilder) Join(table, on string, args ...any) *SelectBuilder {
+	s.joins = append(s.joins, Raw("JOIN "+table+" ON "+on, args...))
+	return s
+}
+
+// LeftJoin добавляет LEFT JOIN
+func (s *SelectBuilder) LeftJoin(table, on string, args ...any) *SelectBuilder {
+	s.joins = append(s.joins, Raw("LEFT JOIN "+table+" ON "+on, args...))
+	return s
+}
+
+// Where добавляет условия, объединяемые через AND. nil пропускается,
+// что удобно для необязательных фильтров.
+func (s *SelectBuilder) Where(exprs ...Expr) *SelectBuilder {
+	s.where = appendExprs(s.where, exprs)
+	return s
+}
+
+// GroupBy задаёт GROUP BY
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+// Having добавляет условия HAVING
+func (s *SelectBuilder) Having(exprs ...Expr) *SelectBuilder {
+	s.having = appendExprs(s.having, exprs)
+	return s
+}
+
+// OrderBy добавляет выражения сортировки как есть — только для строк из кода, не из запроса пользователя
+func (s *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, exprs...)
+	return s
+}
+
+// Sortable задаёт белый список: публичное имя поля -> SQL-выражение
+func (s *SelectBuilder) Sortable(allowed map[string]string) *SelectBuilder {
+	s.sortable = allowed
+	return s
+}
+
+// SortBy принимает сортировку из пользовательского ввода в виде "username,-created_at".
+// Минус означает DESC. Каждое поле проверяется по белому списку из Sortable.
+func (s *SelectBuilder) SortBy(spec string) *SelectBuilder {
+	s.sortSpec = spec
+	return s
+}
+
+// Limit задаёт LIMIT (передаётся параметром)
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = &n
+	return s
+}
+
+// Offset задаёт OFFSET (передаётся параметром)
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = &n
+	return s
+}
+
+// ToSQL рендерит запрос для диалекта d
+func (s *SelectBuilder) ToSQL(d Dialect) (string, []any, error) {
+	if s.from == "" {
+		return "", nil, errors.New("qb: SELECT без FROM")
+	}
+	order, err := s.sortClauses()
+	if err != nil {
+		return "", nil, err
+	}
+
+	b := &buffer{d: d}
+	b.write("SELECT ")
+	if len(s.columns) == 0 {
+		b.write("*")
+	} else {
+		b.write(strings.Join(s.columns, ", "))
+	}
+	b.write(" FROM ", s.from)
+	for _, j := range s.joins {
+		b.write(" ")
+		j.writeTo(b)
+	}
+	writeConditions(b, " WHERE ", s.where)
+	if len(s.groupBy) > 0 {
+		b.write(" GROUP BY ", strings.Join(s.groupBy, ", "))
+	}
+	writeConditions(b, " HAVING ", s.having)
+	if len(order) > 0 {
+		b.write(" ORDER BY ", strings.Join(order, ", "))
+	}
+	if s.limit != nil {
+		b.write(" LIMIT ")
+		b.arg(*s.limit)
+	}
+	if s.offset != nil {
+		b.write(" OFFSET ")
+		b.arg(*s.offset)
+	}
+	return b.result()
+}
+
+func (s *SelectBuilder) sortClauses() ([]string, error) {
+	order := slices.Clone(s.orderBy)
+	if strings.TrimSpace(s.sortSpec) == "" {
+		return order, nil
+	}
+
+	for _, key := range strings.Split(s.sortSpec, ",") {
+		key = strings.TrimSpace(key)
+		dir := "ASC"
+		switch {
+		case strings.HasPrefix(key, "-"):
+			key, dir = key[1:], "DESC"
+		case strings.HasPrefix(key, "+"):
+			key = key[1:]
+		}
+
+		expr, ok := s.sortable[key]
+		if !ok {
+			allowed := slices.Sorted(maps.Keys(s.sortable))
+			return nil, fmt.Errorf("%w: %q (допустимо: %s)", ErrSortNotAllowed, key, strings.Join(allowed, ", "))
+		}
+		order = append(order, expr+" "+dir)
+	}
+	return order, nil
+}
+
+// InsertBuilder строит INSERT, в том числе многострочный
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]any
+	suffix    []Expr
+	returning []string
+	err       error
+}
+
+// Insert начинает INSERT в таблицу
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns задаёт список колонок
+func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	i.columns = columns
+	return i
+}
+
+// Values добавляет строку значений; число значений должно совпадать с числом колонок
+func (i *InsertBuilder) Values(values ...any) *InsertBuilder {
+	if len(values) != len(i.columns) && i.err == nil {
+		i.err = fmt.Errorf("qb: INSERT в %s: %d колонок, но %d значений", i.table, len(i.columns), len(values))
+	}
+	i.rows = append(i.rows, values)
+	return i
+}
+
+// SetMap задаёт колонки и одну строку значений из map (колонки сортируются по имени)
+func (i *InsertBuilder) SetMap(m map[string]any) *InsertBuilder {
+	i.columns = slices.Sorted(maps.Keys(m))
+	row := make([]any, len(i.columns))
+	for n, c := range i.columns {
+		row[n] = m[c]
+	}
+	i.rows = [][]any{row}
+	return i
+}
+
+// OnConflict добавляет хвост ON CONFLICT ..., например "(email) DO NOTHING"
+func (i *InsertBuilder) OnConflict(clause string, args ...any) *InsertBuilder {
+	i.suffix = append(i.suffix, Raw("ON CONFLICT "+clause, args...))
+	return i
+}
+
+// Returning добавляет RETURNING
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.returning = columns
+	return i
+}
+
+// ToSQL рендерит запрос для диалекта d
+func (i *InsertBuilder) ToSQL(d Dialect) (string, []any, error) {
+	if i.err != nil {
+		return "", nil, i.err
+	}
+	if len(i.rows) == 0 {
+		return "", nil, fmt.Errorf("qb: INSERT в %s без значений", i.table)
+	}
+
+	b := &buffer{d: d}
+	b.write("INSERT INTO ", i.table, " (", strings.Join(i.columns, ", "), ") VALUES ")
+	for n, row := range i.rows {
+		if n > 0 {
+			b.write(", ")
+		}
+		b.write("(")
+		for k, v := range row {
+			if k > 0 {
+				b.write(", ")
+			}
+			b.arg(v)
+		}
+		b.write(")")
+	}
+	for _, x := range i.suffix {
+		b.write(" ")
+		x.writeTo(b)
+	}
+	writeReturning(b, i.returning)
+	return b.result()
+}
+
+// UpdateBuilder строит UPDATE
+type UpdateBuilder struct {
+	table     string
+	columns   []string
+	values    []any
+	where     []Expr
+	returning []string
+}
+
+// Update начинает UPDATE таблицы
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set добавляет присваивание column = value; value может быть Expr, например Raw("NOW()")
+func (u *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	u.columns = append(u.columns, column)
+	u.values = append(u.values, value)
+	return u
+}
+
+// SetMap добавляет присваивания из map (в порядке имён колонок)
+func (u *UpdateBuilder) SetMap(m map[string]any) *UpdateBuilder {
+	for _, c := range slices.Sorted(maps.Keys(m)) {
+		u.Set(c, m[c])
+	}
+	return u
+}
+
+// Where добавляет условия через AND
+func (u *UpdateBuilder) Where(exprs ...Expr) *UpdateBuilder {
+	u.where = appendExprs(u.where, exprs)
+	return u
+}
+
+// Returning добавляет RETURNING
+func (u *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	u.returning = columns
+	return u
+}
+
+// ToSQL рендерит запрос для диалекта d
+func (u *UpdateBuilder) ToSQL(d Dialect) (string, []any, error) {
+	if len(u.columns) == 0 {
+		return "", nil, fmt.Errorf("qb: UPDATE %s без SET", u.table)
+	}
+	if len(u.where) == 0 {
+		return "", nil, ErrMissingWhere
+	}
+
+	b := &buffer{d: d}
+	b.write("UPDATE ", u.table, " SET ")
+	for n, c := range u.columns {
+		if n > 0 {
+			b.write(", ")
+		}
+		b.write(c, " = ")
+		b.arg(u.values[n])
+	}
+	writeConditions(b, " WHERE ", u.where)
+	writeReturning(b, u.returning)
+	return b.result()
+}
+
+// DeleteBuilder строит DELETE
+type DeleteBuilder struct {
+	table     string
+	where     []Expr
+	returning []string
+}
+
+// Delete начинает DELETE из таблицы
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where добавляет условия через AND
+func (del *DeleteBuilder) Where(exprs ...Expr) *DeleteBuilder {
+	del.where = appendExprs(del.where, exprs)
+	return del
+}
+
+// Returning добавляет RETURNING
+func (del *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	del.returning = columns
+	return del
+}
+
+// ToSQL рендерит запрос для диалекта d
+func (del *DeleteBuilder) ToSQL(d Dialect) (string, []any, error) {
+	if len(del.where) == 0 {
+		return "", nil, ErrMissingWhere
+	}
+
+	b := &buffer{d: d}
+	b.write("DELETE FROM ", del.table)
+	writeConditions(b, " WHERE ", del.where)
+	writeReturning(b, del.returning)
+	return b.result()
+}
+
+func appendExprs(dst, exprs []Expr) []Expr {
+	for _, e := range exprs {
+		if e != nil {
+			dst = append(dst, e)
+		}
+	}
+	return dst
+}
+
+func writeConditions(b *buffer, keyword string, exprs []Expr) {
+	if len(exprs) == 0 {
+		return
+	}
+	b.write(keyword)
+	for n, e := range exprs {
+		if n > 0 {
+			b.write(" AND ")
+		}
+		// And/Or сами берут себя в скобки, а Raw может содержать OR — его оборачиваем,
+		// чтобы условие не «протекло» в соседний AND
+		if _, raw := e.(rawExpr); raw && len(exprs) > 1 {
+			b.write("(")
+			e.writeTo(b)
+			b.write(")")
+			continue
+		}
+		e.writeTo(b)
+	}
+}
+
+func writeReturning(b *buffer, columns []string) {
+	if len(columns) > 0 {
+		b.write(" RETURNING ", strings.Join(columns, ", "))
+	}
+}
+
+func (b *buffer) result() (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.sb.String(), b.args, nil
+}
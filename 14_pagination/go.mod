@@ -0,0 +1,23 @@
+module github.com/akozadaev/go_db_20/14_pagination
+
+go 1.25.1
+
+require (
+	github.com/akozadaev/go_db_20/12_generic_scan v0.0.0
+	github.com/akozadaev/go_db_20/13_query_builder v0.0.0
+	github.com/jackc/pgx/v5 v5.7.6
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sync v0.13.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
+)
+
+replace (
+	github.com/akozadaev/go_db_20/12_generic_scan => ../12_generic_scan
+	github.com/akozadaev/go_db_20/13_query_builder => ../13_query_builder
+)
@@ -0,0 +1,52 @@
+package paginate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor — курсор повреждён или сформирован не этим пакетом
+var ErrInvalidCursor = errors.New("paginate: некорректный курсор")
+
+// Key — позиция строки в порядке (created_at, id)
+type Key struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+type direction string
+
+const (
+	forward  direction = "next"
+	backward direction = "prev"
+)
+
+// cursorData — содержимое курсора; клиенту он отдаётся непрозрачной строкой
+type cursorData struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+	Dir       direction `json:"d"`
+}
+
+func encodeCursor(k Key, dir direction) string {
+	raw, _ := json.Marshal(cursorData{CreatedAt: k.CreatedAt, ID: k.ID, Dir: dir})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (Key, direction, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Key{}, "", ErrInvalidCursor
+	}
+
+	var c cursorData
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Key{}, "", ErrInvalidCursor
+	}
+	if c.Dir != forward && c.Dir != backward || c.CreatedAt.IsZero() {
+		return Key{}, "", ErrInvalidCursor
+	}
+	return Key{CreatedAt: c.CreatedAt, ID: c.ID}, c.Dir, nil
+}
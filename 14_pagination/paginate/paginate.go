@@ -0,0 +1,206 @@
+// Package paginate — постраничная выборка поверх qb и rowscan.
+//
+// Offset подходит для небольших таблиц и перехода на произвольную страницу.
+// Keyset листает по (created_at, id) через непрозрачные курсоры: скорость не
+// зависит от глубины, а вставки между запросами не сдвигают страницы.
+package paginate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+	"github.com/akozadaev/go_db_20/13_query_builder/qb"
+)
+
+const (
+	// DefaultLimit — размер страницы, если клиент его не указал
+	DefaultLimit = 20
+	// MaxLimit — верхняя граница размера страницы
+	MaxLimit = 100
+)
+
+func clampLimit(n int) int {
+	switch {
+	case n <= 0:
+		return DefaultLimit
+	case n > MaxLimit:
+		return MaxLimit
+	}
+	return n
+}
+
+// Source — где выполнять запросы и в каком диалекте их рендерить
+type Source struct {
+	Q       rowscan.Querier
+	Dialect qb.Dialect
+}
+
+// OffsetRequest — номер страницы (с 1) и её размер
+type OffsetRequest struct {
+	Page      int
+	Size      int
+	WithTotal bool
+}
+
+// OffsetPage — страница offset-пагинации
+type OffsetPage[T any] struct {
+	Items   []T
+	Page    int
+	Size    int
+	HasNext bool
+	Total   *int64 // заполняется только при WithTotal
+}
+
+// Offset выбирает страницу через LIMIT/OFFSET.
+// base должен содержать фильтры и устойчивый ORDER BY (например, с id в конце).
+func Offset[T any](ctx context.Context, src Source, base *qb.SelectBuilder, req OffsetRequest) (*OffsetPage[T], error) {
+	size := clampLimit(req.Size)
+	pageNum := max(req.Page, 1)
+
+	page := &OffsetPage[T]{Page: pageNum, Size: size}
+	if req.WithTotal {
+		total, err := count(ctx, src, base)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	// Берём на одну строку больше, чтобы узнать, есть ли следующая страница
+	query, args, err := base.Clone().Limit(size + 1).Offset((pageNum - 1) * size).ToSQL(src.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	items, err := rowscan.QueryAll[T](ctx, src.Q, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки страницы: %w", err)
+	}
+
+	if len(items) > size {
+		items, page.HasNext = items[:size], true
+	}
+	page.Items = items
+	return page, nil
+}
+
+// Keyset описывает ключ сортировки для курсорной пагинации.
+// Строки отдаются от новых к старым: created_at DESC, id DESC.
+type Keyset[T any] struct {
+	CreatedAt string      // выражение колонки created_at, например "a.created_at"
+	ID        string      // выражение колонки id, например "a.id"
+	Key       func(T) Key // извлекает ключ из строки результата
+}
+
+// KeysetRequest — курсор из предыдущего ответа (пустой — первая страница)
+type KeysetRequest struct {
+	Cursor    string
+	Limit     int
+	WithTotal bool
+}
+
+// KeysetPage — страница курсорной пагинации
+type KeysetPage[T any] struct {
+	Items []T
+	Next  string // курсор следующей (более старой) страницы, пустой — конец
+	Prev  string // курсор предыдущей (более новой) страницы, пустой — начало
+	Total *int64 // заполняется только при WithTotal
+}
+
+// Fetch выбирает страницу после или до позиции курсора.
+// base содержит фильтры, но не ORDER BY и LIMIT — их добавляет Fetch.
+func (k Keyset[T]) Fetch(ctx context.Context, src Source, base *qb.SelectBuilder, req KeysetRequest) (*KeysetPage[T], error) {
+	limit := clampLimit(req.Limit)
+
+	dir := forward
+	q := base.Clone()
+	if req.Cursor != "" {
+		var (
+			key Key
+			err error
+		)
+		key, dir, err = decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		cmp := "<"
+		if dir == backward {
+			cmp = ">"
+		}
+		// Сравнение кортежей корректно обрабатывает одинаковые created_at
+		q.Where(qb.Raw(fmt.Sprintf("(%s, %s) %s (?, ?)", k.CreatedAt, k.ID, cmp), key.CreatedAt, key.ID))
+	}
+
+	order := "DESC"
+	if dir == backward {
+		order = "ASC"
+	}
+	q.OrderBy(k.CreatedAt+" "+order, k.ID+" "+order).Limit(limit + 1)
+
+	page := &KeysetPage[T]{}
+	if req.WithTotal {
+		total, err := count(ctx, src, base)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	query, args, err := q.ToSQL(src.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	items, err := rowscan.QueryAll[T](ctx, src.Q, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки страницы: %w", err)
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if dir == backward {
+		// Назад читали в обратном порядке — возвращаем привычный DESC
+		slices.Reverse(items)
+	}
+	page.Items = items
+
+	if len(items) == 0 {
+		return page, nil
+	}
+	first, last := k.Key(items[0]), k.Key(items[len(items)-1])
+	switch dir {
+	case forward:
+		if hasMore {
+			page.Next = encodeCursor(last, forward)
+		}
+		if req.Cursor != "" {
+			page.Prev = encodeCursor(first, backward)
+		}
+	case backward:
+		page.Next = encodeCursor(last, forward)
+		if hasMore {
+			page.Prev = encodeCursor(first, backward)
+		}
+	}
+	return page, nil
+}
+
+// count считает строки, подходящие под фильтры base
+func count(ctx context.Context, src Source, base *qb.SelectBuilder) (int64, error) {
+	query, args, err := base.ToSQL(src.Dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	type total struct {
+		N int64 `db:"n"`
+	}
+	t, err := rowscan.QueryOne[total](ctx, src.Q, "SELECT COUNT(*) AS n FROM ("+query+") AS page_total", args...)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта строк: %w", err)
+	}
+	return t.N, nil
+}
@@ -0,0 +1,177 @@
+// Package stream отдаёт большие выборки по одной строке, не загружая их в память.
+//
+// Rows и Cursor возвращают iter.Seq2 — цикл range можно прервать в любой момент,
+// и запрос будет закрыт. Chan переносит итератор в канал с ограниченным буфером:
+// медленный потребитель притормаживает чтение из базы (backpressure).
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+)
+
+// errStop — внутренний сигнал остановки, когда потребитель вышел из range
+var errStop = errors.New("stream: остановлено потребителем")
+
+// Rows выполняет запрос и отдаёт строки по одной.
+// Подходит и для pgx, и для database/sql — оба драйвера читают строки из сокета постепенно.
+func Rows[T any](ctx context.Context, q rowscan.Querier, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := q.QueryRows(ctx, query, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		err = rowscan.ScanEach(rows, func(item T) error {
+			if !yield(item, nil) {
+				return errStop
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStop) {
+			yield(zero, err)
+		}
+	}
+}
+
+// TxBeginner — *pgxpool.Pool или *pgx.Conn
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+}
+
+// DefaultFetchSize — сколько строк забирать одним FETCH
+const DefaultFetchSize = 1000
+
+// Cursor читает выборку через серверный курсор PostgreSQL (DECLARE ... CURSOR)
+// порциями по fetchSize строк. Курсор живёт в отдельной read-only транзакции,
+// которая откатывается по завершении или при выходе из range.
+func Cursor[T any](ctx context.Context, db TxBeginner, fetchSize int, query string, args ...any) iter.Seq2[T, error] {
+	if fetchSize <= 0 {
+		fetchSize = DefaultFetchSize
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		tx, err := db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+		if err != nil {
+			yield(zero, fmt.Errorf("не удалось начать транзакцию для курсора: %w", err))
+			return
+		}
+		// Курсор только читает, поэтому коммит не нужен — откат закрывает и курсор
+		defer tx.Rollback(context.WithoutCancel(ctx))
+
+		//sqlvet:ignore query — текст запроса от вызывающего кода, значения идут параметрами args
+		if _, err = tx.Exec(ctx, "DECLARE stream_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+			yield(zero, fmt.Errorf("ошибка объявления курсора: %w", err))
+			return
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM stream_cursor", fetchSize)
+		for {
+			//sqlvet:ignore в FETCH подставляется только число fetchSize
+			rows, err := tx.Query(ctx, fetch)
+			if err != nil {
+				yield(zero, fmt.Errorf("ошибка FETCH: %w", err))
+				return
+			}
+
+			n := 0
+			err = rowscan.ScanEach(rowscan.FromPgx(rows), func(item T) error {
+				n++
+				if !yield(item, nil) {
+					return errStop
+				}
+				return nil
+			})
+			switch {
+			case errors.Is(err, errStop):
+				return
+			case err != nil:
+				yield(zero, err)
+				return
+			case n < fetchSize:
+				// Последняя порция — курсор исчерпан
+				return
+			}
+		}
+	}
+}
+
+// Chan запускает чтение seq в отдельной горутине и отдаёт строки в канал
+// с буфером buffer. Когда буфер заполнен, чтение из базы ждёт потребителя.
+// Отмена ctx останавливает чтение и закрывает канал. wait возвращает
+// ошибку чтения после закрытия канала.
+func Chan[T any](ctx context.Context, seq iter.Seq2[T, error], buffer int) (items <-chan T, wait func() error) {
+	ch := make(chan T, buffer)
+	done := make(chan struct{})
+	var readErr error
+
+	go func() {
+		defer close(done)
+		defer close(ch)
+
+		for item, err := range seq {
+			if err != nil {
+				readErr = err
+				return
+			}
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				readErr = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return ch, func() error {
+		<-done
+		return readErr
+	}
+}
+
+// Process обрабатывает строки из seq параллельно в workers горутинах.
+// Первая ошибка (чтения или обработчика) отменяет остальную работу и возвращается.
+func Process[T any](ctx context.Context, seq iter.Seq2[T, error], workers int, fn func(context.Context, T) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	items, wait := Chan(ctx, seq, workers)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if ctx.Err() != nil {
+					return
+				}
+				if err := fn(ctx, item); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Если воркеры вышли по ошибке, дочитываем канал, чтобы горутина чтения завершилась
+	for range items {
+	}
+
+	if err := context.Cause(ctx); err != nil && ctx.Err() != nil {
+		return err
+	}
+	return wait()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Форматы времени, которые принимаются при загрузке:
+// RFC 3339 (так пишет export) и текстовый вид PostgreSQL / SQLite
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+}
+
+// coerce приводит значение из файла к типу целевой колонки
+func coerce(k kind, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	// JSON-колонка принимает объекты и массивы из JSONL как есть
+	if k == kindJSON {
+		if s, ok := v.(string); ok {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("некорректный JSON")
+			}
+			return s, nil
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(raw), nil
+	}
+
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = strings.TrimSpace(x)
+	case json.Number:
+		s = x.String()
+	case bool:
+		s = strconv.FormatBool(x)
+	default:
+		return nil, fmt.Errorf("значение %v (%T) нельзя записать в скалярную колонку", v, v)
+	}
+
+	switch k {
+	case kindInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ожидалось целое число, получено %q", s)
+		}
+		return n, nil
+	case kindFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ожидалось число, получено %q", s)
+		}
+		return f, nil
+	case kindDecimal:
+		// Проверяем формат, но передаём строкой, чтобы не потерять точность
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, fmt.Errorf("ожидалось десятичное число, получено %q", s)
+		}
+		return s, nil
+	case kindBool:
+		switch strings.ToLower(s) {
+		case "true", "t", "1", "yes", "y":
+			return true, nil
+		case "false", "f", "0", "no", "n":
+			return false, nil
+		}
+		return nil, fmt.Errorf("ожидалось логическое значение, получено %q", s)
+	case kindTime:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("не удалось разобрать время %q", s)
+	case kindUUID:
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный UUID %q", s)
+		}
+		return id.String(), nil
+	case kindBytes:
+		if rest, ok := strings.CutPrefix(s, `\x`); ok {
+			b, err := hex.DecodeString(rest)
+			if err != nil {
+				return nil, fmt.Errorf("некорректный hex: %w", err)
+			}
+			return b, nil
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("ожидались байты в \\x-hex или base64")
+		}
+		return b, nil
+	}
+	return s, nil
+}
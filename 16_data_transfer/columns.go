@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// kind — обобщённый тип колонки, по которому выбирается формат значения в файле
+type kind int
+
+const (
+	kindText kind = iota
+	kindInt
+	kindFloat
+	kindDecimal
+	kindBool
+	kindTime
+	kindUUID
+	kindJSON
+	kindBytes
+)
+
+type column struct {
+	Name string
+	Kind kind
+}
+
+// intTypes — целочисленные типы PostgreSQL (имена pgx) и объявленные типы SQLite.
+// Только точные имена: поиск подстроки INT зацепил бы INTERVAL и POINT.
+var intTypes = map[string]bool{
+	"INT": true, "INT2": true, "INT4": true, "INT8": true, "INTEGER": true,
+	"SMALLINT": true, "BIGINT": true, "TINYINT": true, "MEDIUMINT": true,
+	"SERIAL": true, "SMALLSERIAL": true, "BIGSERIAL": true,
+	"UNSIGNED BIG INT": true,
+}
+
+// kindOf сопоставляет имя типа из драйвера (pgx отдаёт INT4, JSONB, UUID...,
+// SQLite — объявленный тип колонки) с обобщённым типом
+func kindOf(dbType string) kind {
+	t := strings.ToUpper(strings.TrimSpace(dbType))
+	// Размерность из объявления SQLite не влияет на вид: NUMERIC(10,2) — NUMERIC
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	switch {
+	case t == "UUID":
+		return kindUUID
+	case t == "JSON" || t == "JSONB":
+		return kindJSON
+	case intTypes[t]:
+		return kindInt
+	case t == "NUMERIC" || t == "DECIMAL":
+		return kindDecimal
+	case strings.HasPrefix(t, "FLOAT") || t == "REAL" || strings.HasPrefix(t, "DOUBLE"):
+		return kindFloat
+	case strings.HasPrefix(t, "BOOL"):
+		return kindBool
+	case strings.HasPrefix(t, "TIMESTAMP") || t == "DATE" || t == "DATETIME":
+		return kindTime
+	case t == "BYTEA" || t == "BLOB":
+		return kindBytes
+	}
+	return kindText
+}
+
+func columnsOf(rows *sql.Rows) ([]column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]column, len(types))
+	for i, ct := range types {
+		cols[i] = column{Name: ct.Name(), Kind: kindOf(ct.DatabaseTypeName())}
+	}
+	return cols, nil
+}
+
+// normalize приводит значение драйвера к одному из типов:
+// nil, string, int64, float64, bool, time.Time, json.RawMessage, []byte
+func normalize(k kind, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch k {
+	case kindJSON:
+		switch x := v.(type) {
+		case []byte:
+			return json.RawMessage(x), nil
+		case string:
+			return json.RawMessage(x), nil
+		}
+	case kindBytes:
+		if b, ok := v.([]byte); ok {
+			return b, nil
+		}
+	case kindTime:
+		switch x := v.(type) {
+		case time.Time:
+			return x, nil
+		case string:
+			// SQLite хранит TIMESTAMP как текст, если вставлен не через драйвер
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", time.DateOnly} {
+				if t, err := time.Parse(layout, x); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("не удалось разобрать время %q", x)
+		}
+	case kindInt:
+		if x, ok := v.(int64); ok {
+			return x, nil
+		}
+	case kindFloat:
+		if x, ok := v.(float64); ok {
+			return x, nil
+		}
+	case kindBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int64:
+			return x != 0, nil
+		}
+	}
+
+	// Всё остальное (UUID и NUMERIC от pgx уже строки) — как текст
+	switch x := v.(type) {
+	case []byte:
+		return string(x), nil
+	case string:
+		return x, nil
+	default:
+		return fmt.Sprint(x), nil
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+// dialect — тот же тип, что у пакета ident: квотирование имён зависит от диалекта
+type dialect = ident.Dialect
+
+const (
+	postgres = ident.Postgres
+	sqlite   = ident.SQLite
+)
+
+// openDB открывает PostgreSQL (DSN postgres://... или host=...) или файл SQLite
+func openDB(ctx context.Context, dsn string) (*sql.DB, dialect, error) {
+	driver, d := "sqlite3", sqlite
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") || strings.Contains(dsn, "host=") {
+		driver, d = "pgx", postgres
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, d, fmt.Errorf("ошибка при открытии подключения: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, d, fmt.Errorf("не удалось подключиться к базе данных: %w", err)
+	}
+	return db, d, nil
+}
+
+// trimQuery убирает завершающую точку с запятой, чтобы запрос можно было вложить в COPY (...)
+func trimQuery(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \n\t")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+// presets — готовые выборки для аналитиков
+var presets = map[string]string{
+	// Тот же JOIN, что в printAccountsAndRoles, но с аккаунтами без ролей и сессий
+	"accounts_roles_sessions": `
+		SELECT a.id, a.username, a.email, a.is_active, a.created_at,
+		       r.name AS role, s.id AS session_id, s.expires_at
+		FROM accounts a
+		LEFT JOIN account_roles ar ON a.id = ar.account_id
+		LEFT JOIN roles r ON ar.role_id = r.id
+		LEFT JOIN sessions s ON s.account_id = a.id
+		ORDER BY a.username, r.name`,
+}
+
+type exportOptions struct {
+	dsn    string
+	table  string
+	query  string
+	preset string
+	format string
+	out    string
+}
+
+func runExport(ctx context.Context, args []string) error {
+	var opts exportOptions
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&opts.dsn, "dsn", defaultDSN, "postgres://... или путь к файлу SQLite")
+	fs.StringVar(&opts.table, "table", "", "выгрузить таблицу целиком")
+	fs.StringVar(&opts.query, "query", "", "выгрузить результат произвольного SELECT")
+	fs.StringVar(&opts.preset, "preset", "", "готовая выборка: accounts_roles_sessions")
+	fs.StringVar(&opts.format, "format", "csv", "формат: csv, jsonl, parquet")
+	fs.StringVar(&opts.out, "out", "-", "файл результата, - для stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, d, err := openDB(ctx, opts.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	query, err := exportQuery(ctx, db, d, opts)
+	if err != nil {
+		return err
+	}
+
+	out, closeOut, err := openOutput(opts.out)
+	if err != nil {
+		return err
+	}
+
+	// Для CSV PostgreSQL сам форматирует строки через COPY — это быстрее построчного чтения
+	if d == postgres && opts.format == "csv" {
+		err = copyToCSV(ctx, db, query, out)
+	} else {
+		err = exportRows(ctx, db, d, query, opts.format, out)
+	}
+	if cerr := closeOut(); err == nil {
+		err = cerr
+	}
+	if err != nil && opts.out != "-" {
+		// Не оставляем обрезанный файл, который можно принять за полную выгрузку
+		os.Remove(opts.out)
+	}
+	return err
+}
+
+// exportQuery определяет, что выгружать: таблицу, SELECT или готовую выборку
+func exportQuery(ctx context.Context, db *sql.DB, d dialect, opts exportOptions) (string, error) {
+	set := 0
+	for _, s := range []string{opts.table, opts.query, opts.preset} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", errors.New("укажите ровно один из флагов -table, -query или -preset")
+	}
+
+	switch {
+	case opts.query != "":
+		return trimQuery(opts.query), nil
+	case opts.preset != "":
+		q, ok := presets[opts.preset]
+		if !ok {
+			return "", fmt.Errorf("неизвестная выборка %q", opts.preset)
+		}
+		return trimQuery(q), nil
+	}
+
+	// Имя таблицы приходит из флага — в запрос попадает только имя, найденное в схеме
+	names, err := ident.Load(ctx, db, d)
+	if err != nil {
+		return "", err
+	}
+	table, err := names.Table(opts.table)
+	if err != nil {
+		return "", err
+	}
+	return "SELECT * FROM " + table, nil
+}
+
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "-" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	return f, f.Close, nil
+}
+
+// exportRows читает строки по одной и передаёт их writer'у — память не растёт с объёмом выборки
+func exportRows(ctx context.Context, db *sql.DB, d dialect, query, format string, out io.Writer) error {
+	// Произвольный SELECT выполняем в read-only транзакции, чтобы выгрузка ничего не изменила
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: d == postgres})
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := columnsOf(rows)
+	if err != nil {
+		return err
+	}
+	w, err := newRowWriter(format, out, cols)
+	if err != nil {
+		return err
+	}
+
+	raw := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	values := make([]any, len(cols))
+
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		for i, c := range cols {
+			if values[i], err = normalize(c.Kind, raw[i]); err != nil {
+				return fmt.Errorf("строка %d, колонка %s: %w", n+1, c.Name, err)
+			}
+		}
+		if err := w.WriteRow(values); err != nil {
+			return fmt.Errorf("ошибка записи строки %d: %w", n+1, err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Выгружено строк: %d\n", n)
+	return nil
+}
+
+// copyToCSV выгружает результат через COPY (...) TO STDOUT на соединении pgx
+func copyToCSV(ctx context.Context, db *sql.DB, query string, out io.Writer) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+
+		tx, err := pgConn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return fmt.Errorf("не удалось начать транзакцию: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		tag, err := tx.Conn().PgConn().CopyTo(ctx, out, "COPY ("+query+") TO STDOUT WITH (FORMAT csv, HEADER true)")
+		if err != nil {
+			return fmt.Errorf("ошибка COPY: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Выгружено строк: %d\n", tag.RowsAffected())
+		return tx.Commit(ctx)
+	})
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+// Режимы обработки конфликтов по ключу
+const (
+	modeInsert = "insert" // обычный INSERT, конфликт — отказ строки
+	modeUpsert = "upsert" // ON CONFLICT (key) DO UPDATE
+	modeSkip   = "skip"   // ON CONFLICT (key) DO NOTHING
+)
+
+// Сколько строк фиксировать одной транзакцией
+const importBatchSize = 500
+
+type importOptions struct {
+	dsn     string
+	file    string
+	format  string
+	table   string
+	mapping string
+	mode    string
+	key     string
+	rejects string
+}
+
+type importStats struct {
+	read, written, skipped, rejected int
+}
+
+// targetColumn — колонка таблицы, в которую пишется значение из файла
+type targetColumn struct {
+	column
+	Source string
+}
+
+func runImport(ctx context.Context, args []string) error {
+	var opts importOptions
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.StringVar(&opts.dsn, "dsn", defaultDSN, "postgres://... или путь к файлу SQLite")
+	fs.StringVar(&opts.file, "file", "", "входной файл CSV или JSONL (- для stdin)")
+	fs.StringVar(&opts.format, "format", "", "формат: csv, jsonl (по умолчанию — по расширению файла)")
+	fs.StringVar(&opts.table, "table", "", "целевая таблица")
+	fs.StringVar(&opts.mapping, "map", "", "сопоставление колонок: файл=таблица,... (файл=- — пропустить колонку)")
+	fs.StringVar(&opts.mode, "mode", modeInsert, "режим: insert, upsert, skip")
+	fs.StringVar(&opts.key, "key", "", "колонки конфликта для upsert/skip, например email")
+	fs.StringVar(&opts.rejects, "rejects", "rejects.csv", "файл для отклонённых строк")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.file == "" || opts.table == "" {
+		return errors.New("флаги -file и -table обязательны")
+	}
+	if opts.format == "" {
+		opts.format = strings.TrimPrefix(filepath.Ext(opts.file), ".")
+	}
+	switch opts.mode {
+	case modeInsert, modeSkip:
+	case modeUpsert:
+		if opts.key == "" {
+			return errors.New("для режима upsert нужен флаг -key")
+		}
+	default:
+		return fmt.Errorf("неизвестный режим %q (insert, upsert, skip)", opts.mode)
+	}
+
+	mapping, err := parseMapping(opts.mapping)
+	if err != nil {
+		return err
+	}
+
+	db, d, err := openDB(ctx, opts.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tableCols, err := tableColumns(ctx, db, d, opts.table)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(opts.file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	reader, err := newRecordReader(opts.format, in)
+	if err != nil {
+		return err
+	}
+
+	rej := &rejectWriter{path: opts.rejects}
+	defer rej.Close()
+
+	keyCols, err := parseKey(opts.key, tableCols)
+	if err != nil {
+		return err
+	}
+
+	imp := &importer{
+		db:        db,
+		names:     ident.NewSet(d).Allow(opts.table, slices.Collect(maps.Keys(tableCols))...),
+		opts:      opts,
+		mapping:   mapping,
+		tableCols: tableCols,
+		keyCols:   keyCols,
+		rejects:   rej,
+	}
+
+	// Для CSV колонки известны заранее — ошибки сопоставления видны до загрузки
+	if header := reader.Header(); header != nil {
+		if _, err := imp.targets(header); err != nil {
+			return err
+		}
+	}
+
+	stats, err := imp.run(ctx, reader)
+	fmt.Fprintf(os.Stderr, "Прочитано: %d, записано: %d, пропущено: %d, отклонено: %d\n",
+		stats.read, stats.written, stats.skipped, stats.rejected)
+	if stats.rejected > 0 {
+		fmt.Fprintf(os.Stderr, "Отклонённые строки: %s\n", opts.rejects)
+	}
+	return err
+}
+
+// parseMapping разбирает "src=dst,src2=-" в map
+func parseMapping(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	if s == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		src, dst, ok := strings.Cut(pair, "=")
+		src, dst = strings.TrimSpace(src), strings.TrimSpace(dst)
+		if !ok || src == "" || dst == "" {
+			return nil, fmt.Errorf("некорректное сопоставление %q, ожидалось файл=таблица", pair)
+		}
+		m[src] = dst
+	}
+	return m, nil
+}
+
+// parseKey разбирает -key и проверяет, что колонки есть в таблице
+func parseKey(key string, tableCols map[string]column) ([]string, error) {
+	var cols []string
+	for _, k := range strings.Split(key, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, ok := tableCols[k]; !ok {
+			return nil, fmt.Errorf("колонки ключа %q нет в таблице", k)
+		}
+		cols = append(cols, k)
+	}
+	return cols, nil
+}
+
+// tableColumns читает колонки таблицы и их типы из схемы
+func tableColumns(ctx context.Context, db *sql.DB, d dialect, table string) (map[string]column, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if d == postgres {
+		rows, err = db.QueryContext(ctx, `
+			SELECT column_name, udt_name
+			FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1
+			ORDER BY ordinal_position`, table)
+	} else {
+		rows, err = db.QueryContext(ctx, `SELECT name, type FROM pragma_table_info(?)`, table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения колонок %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols := make(map[string]column)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+		cols[name] = column{Name: name, Kind: kindOf(typ)}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("таблица %q не найдена", table)
+	}
+	return cols, nil
+}
+
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	return f, nil
+}
+
+type importer struct {
+	db        *sql.DB
+	names     *ident.Set // таблица и её колонки — всё, что можно вписать в INSERT
+	opts      importOptions
+	mapping   map[string]string
+	tableCols map[string]column
+	keyCols   []string
+	rejects   *rejectWriter
+
+	// Подготовленные INSERT по набору колонок: в JSONL он может меняться от строки к строке
+	stmts map[string]*sql.Stmt
+}
+
+// targets сопоставляет колонки файла с колонками таблицы
+func (imp *importer) targets(sourceCols []string) ([]targetColumn, error) {
+	var targets []targetColumn
+	var unknown []string
+	seen := make(map[string]string)
+
+	for _, src := range sourceCols {
+		dst := src
+		if m, ok := imp.mapping[src]; ok {
+			dst = m
+		}
+		if dst == "-" {
+			continue
+		}
+
+		col, ok := imp.tableCols[dst]
+		if !ok {
+			unknown = append(unknown, src)
+			continue
+		}
+		if prev, dup := seen[dst]; dup {
+			return nil, fmt.Errorf("колонки файла %q и %q указывают на одну колонку %q", prev, src, dst)
+		}
+		seen[dst] = src
+		targets = append(targets, targetColumn{column: col, Source: src})
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("в таблице %s нет колонок для %s; сопоставьте их через -map или пропустите (колонка=-)",
+			imp.opts.table, strings.Join(unknown, ", "))
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("нет ни одной колонки для загрузки")
+	}
+	return targets, nil
+}
+
+func (imp *importer) run(ctx context.Context, reader recordReader) (importStats, error) {
+	var stats importStats
+
+	tx, err := imp.begin(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	inBatch := 0
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		switch {
+		case errors.Is(err, errBadRecord):
+			stats.read++
+			stats.rejected++
+			if err := imp.rejects.Write(rec, err); err != nil {
+				return stats, err
+			}
+			continue
+		case err != nil:
+			return stats, fmt.Errorf("ошибка чтения файла: %w", err)
+		}
+		stats.read++
+
+		written, reason, err := imp.load(ctx, tx, rec)
+		switch {
+		case err != nil:
+			return stats, err
+		case reason != nil:
+			stats.rejected++
+			if err := imp.rejects.Write(rec, reason); err != nil {
+				return stats, err
+			}
+		case written:
+			stats.written++
+		default:
+			stats.skipped++
+		}
+
+		if inBatch++; inBatch == importBatchSize {
+			if err := imp.commit(tx); err != nil {
+				return stats, err
+			}
+			if tx, err = imp.begin(ctx); err != nil {
+				return stats, err
+			}
+			inBatch = 0
+		}
+	}
+
+	err = imp.commit(tx)
+	tx = nil
+	return stats, err
+}
+
+func (imp *importer) begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := imp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	imp.stmts = make(map[string]*sql.Stmt)
+	return tx, nil
+}
+
+func (imp *importer) commit(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита транзакции: %w", err)
+	}
+	return nil
+}
+
+// load записывает одну строку. reason — строка отклонена из-за данных (тип, ограничение), импорт продолжается;
+// err — сбой самого импорта (подготовка запроса, SAVEPOINT), после которого транзакция непригодна.
+// Каждая строка пишется под SAVEPOINT, чтобы нарушение ограничения не обрывало всю пачку.
+func (imp *importer) load(ctx context.Context, tx *sql.Tx, rec record) (written bool, reason, err error) {
+	targets, reason := imp.targets(slices.Sorted(maps.Keys(rec.Values)))
+	if reason != nil {
+		return false, reason, nil
+	}
+
+	args := make([]any, len(targets))
+	for i, t := range targets {
+		if args[i], reason = coerce(t.Kind, rec.Values[t.Source]); reason != nil {
+			return false, fmt.Errorf("колонка %s: %w", t.Name, reason), nil
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+		return false, nil, fmt.Errorf("ошибка SAVEPOINT: %w", err)
+	}
+	// Подготовка после SAVEPOINT: в PostgreSQL её ошибка прерывает транзакцию, и продолжать
+	// пачку нельзя — такой сбой (например, -key без уникального ограничения) возвращается как err
+	stmt, err := imp.stmt(ctx, tx, targets)
+	if err != nil {
+		return false, nil, err
+	}
+	res, reason := stmt.ExecContext(ctx, args...)
+	if reason != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); err != nil {
+			return false, nil, fmt.Errorf("ошибка ROLLBACK TO SAVEPOINT: %w", err)
+		}
+		return false, reason, nil
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_row"); err != nil {
+		return false, nil, fmt.Errorf("ошибка RELEASE SAVEPOINT: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return true, nil, nil
+	}
+	return n > 0, nil, nil
+}
+
+// stmt готовит INSERT для набора колонок с учётом режима конфликта
+func (imp *importer) stmt(ctx context.Context, tx *sql.Tx, targets []targetColumn) (*sql.Stmt, error) {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	cacheKey := strings.Join(names, ",")
+	if s, ok := imp.stmts[cacheKey]; ok {
+		return s, nil
+	}
+
+	query, err := insertSQL(imp.names, imp.opts.table, names, imp.opts.mode, imp.keyCols)
+	if err != nil {
+		return nil, err
+	}
+	s, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подготовки запроса: %w", err)
+	}
+	imp.stmts[cacheKey] = s
+	return s, nil
+}
+
+// insertSQL строит INSERT ... ON CONFLICT, понятный и PostgreSQL, и SQLite (3.24+).
+// Имена берутся только из names: колонка, которой нет в таблице, вернёт ident.ErrUnknown.
+func insertSQL(names *ident.Set, table string, columns []string, mode string, keyCols []string) (string, error) {
+	t, err := names.Table(table)
+	if err != nil {
+		return "", err
+	}
+	quoted := make(map[string]string, len(columns)+len(keyCols))
+	for _, c := range slices.Concat(columns, keyCols) {
+		if quoted[c], err = names.Column(table, c); err != nil {
+			return "", err
+		}
+	}
+
+	cols := make([]string, len(columns))
+	params := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = quoted[c]
+		params[i] = "?"
+		if names.Dialect() == postgres {
+			params[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES (%s)",
+		t, strings.Join(cols, ", "), strings.Join(params, ", "))
+
+	conflict := ""
+	if len(keyCols) > 0 {
+		q := make([]string, len(keyCols))
+		for i, k := range keyCols {
+			q[i] = quoted[k]
+		}
+		conflict = " (" + strings.Join(q, ", ") + ")"
+	}
+
+	switch mode {
+	case modeSkip:
+		sb.WriteString(" ON CONFLICT" + conflict + " DO NOTHING")
+	case modeUpsert:
+		var sets []string
+		for _, c := range columns {
+			if !slices.Contains(keyCols, c) {
+				sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", quoted[c], quoted[c]))
+			}
+		}
+		if len(sets) == 0 {
+			// Обновлять нечего — ведём себя как skip
+			sb.WriteString(" ON CONFLICT" + conflict + " DO NOTHING")
+		} else {
+			sb.WriteString(" ON CONFLICT" + conflict + " DO UPDATE SET " + strings.Join(sets, ", "))
+		}
+	}
+	return sb.String(), nil
+}
+
+// rejectWriter пишет отклонённые строки в CSV: номер строки, причина, исходные данные
+type rejectWriter struct {
+	path string
+	f    *os.File
+	w    *csv.Writer
+}
+
+func (r *rejectWriter) Write(rec record, reason error) error {
+	// Файл создаётся только при первом отказе, чтобы не оставлять пустых файлов
+	if r.f == nil {
+		f, err := os.Create(r.path)
+		if err != nil {
+			return fmt.Errorf("не удалось создать файл отказов: %w", err)
+		}
+		r.f, r.w = f, csv.NewWriter(f)
+		if err := r.w.Write([]string{"line", "reason", "record"}); err != nil {
+			return err
+		}
+	}
+	return r.w.Write([]string{strconv.Itoa(rec.Line), reason.Error(), rec.Raw})
+}
+
+func (r *rejectWriter) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// record — одна входная строка: имя колонки файла -> значение.
+// В CSV значения всегда строки (пустая строка — NULL), в JSONL — типы из JSON.
+type record struct {
+	Line   int
+	Values map[string]any
+	Raw    string
+}
+
+// recordReader читает записи из CSV или JSON Lines
+type recordReader interface {
+	// Header — колонки файла, если они известны заранее (CSV); для JSONL — nil
+	Header() []string
+	Next() (record, error)
+}
+
+func newRecordReader(format string, r io.Reader) (recordReader, error) {
+	switch format {
+	case "csv":
+		return newCSVReader(r)
+	case "jsonl":
+		return &jsonlReader{s: newLineScanner(r)}, nil
+	}
+	return nil, fmt.Errorf("неизвестный формат %q (csv, jsonl)", format)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать заголовок CSV: %w", err)
+	}
+	// Допускаем число полей, отличное от заголовка, — такую строку отклоним с понятной причиной
+	cr.FieldsPerRecord = -1
+	return &csvReader{r: cr, header: header}, nil
+}
+
+func (c *csvReader) Header() []string {
+	return c.header
+}
+
+func (c *csvReader) Next() (record, error) {
+	fields, err := c.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return record{Line: parseErr.Line}, fmt.Errorf("%w: %v", errBadRecord, err)
+	}
+	if err != nil {
+		return record{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+
+	raw, _ := json.Marshal(fields)
+	rec := record{Line: line, Raw: string(raw), Values: make(map[string]any, len(fields))}
+	if len(fields) != len(c.header) {
+		return rec, fmt.Errorf("%w: ожидалось %d полей, получено %d", errBadRecord, len(c.header), len(fields))
+	}
+	for i, name := range c.header {
+		if fields[i] == "" {
+			rec.Values[name] = nil
+		} else {
+			rec.Values[name] = fields[i]
+		}
+	}
+	return rec, nil
+}
+
+type jsonlReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return s
+}
+
+func (j *jsonlReader) Header() []string {
+	return nil
+}
+
+func (j *jsonlReader) Next() (record, error) {
+	for j.s.Scan() {
+		j.line++
+		text := bytes.TrimSpace(j.s.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		rec := record{Line: j.line, Raw: string(text)}
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.UseNumber()
+		if err := dec.Decode(&rec.Values); err != nil {
+			return rec, fmt.Errorf("%w: %v", errBadRecord, err)
+		}
+		return rec, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return record{}, err
+	}
+	return record{}, io.EOF
+}
+
+// errBadRecord — строку файла не удалось разобрать; она уходит в файл отказов, импорт продолжается
+var errBadRecord = errors.New("некорректная запись")
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetWriter пишет строки с динамической схемой, построенной по колонкам результата.
+// Все колонки optional, чтобы NULL сохранялся как NULL.
+type parquetWriter struct {
+	w     *parquet.Writer
+	cols  []column
+	index []int // позиция колонки результата в листьях схемы (Group сортирует поля по имени)
+	batch []parquet.Row
+}
+
+const parquetBatchSize = 1000
+
+func newParquetWriter(w io.Writer, cols []column) (rowWriter, error) {
+	group := parquet.Group{}
+	for _, c := range cols {
+		if _, dup := group[c.Name]; dup {
+			return nil, fmt.Errorf("parquet: повторяющееся имя колонки %q, задайте псевдоним в SELECT", c.Name)
+		}
+		group[c.Name] = parquet.Optional(parquetNode(c.Kind))
+	}
+	schema := parquet.NewSchema("row", group)
+
+	leaf := make(map[string]int)
+	for i, path := range schema.Columns() {
+		leaf[path[0]] = i
+	}
+	index := make([]int, len(cols))
+	for i, c := range cols {
+		index[i] = leaf[c.Name]
+	}
+
+	return &parquetWriter{w: parquet.NewWriter(w, schema), cols: cols, index: index}, nil
+}
+
+func parquetNode(k kind) parquet.Node {
+	switch k {
+	case kindInt:
+		return parquet.Int(64)
+	case kindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case kindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case kindTime:
+		return parquet.Timestamp(parquet.Microsecond)
+	case kindUUID:
+		return parquet.UUID()
+	case kindJSON:
+		return parquet.JSON()
+	case kindBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	}
+	// Текст и NUMERIC (точность не теряется) — строкой UTF-8
+	return parquet.String()
+}
+
+func (p *parquetWriter) WriteRow(values []any) error {
+	row := make(parquet.Row, len(values))
+	for i, v := range values {
+		col := p.index[i]
+		if v == nil {
+			row[col] = parquet.NullValue().Level(0, 0, col)
+			continue
+		}
+
+		pv, err := parquetValue(p.cols[i].Kind, v)
+		if err != nil {
+			return fmt.Errorf("колонка %s: %w", p.cols[i].Name, err)
+		}
+		row[col] = pv.Level(0, 1, col)
+	}
+
+	p.batch = append(p.batch, row)
+	if len(p.batch) >= parquetBatchSize {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *parquetWriter) flush() error {
+	if _, err := p.w.WriteRows(p.batch); err != nil {
+		return err
+	}
+	p.batch = p.batch[:0]
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
+
+func parquetValue(k kind, v any) (parquet.Value, error) {
+	switch k {
+	case kindInt:
+		switch x := v.(type) {
+		case int64:
+			return parquet.Int64Value(x), nil
+		case string:
+			n, err := strconv.ParseInt(x, 10, 64)
+			return parquet.Int64Value(n), err
+		}
+	case kindFloat:
+		switch x := v.(type) {
+		case float64:
+			return parquet.DoubleValue(x), nil
+		case int64:
+			return parquet.DoubleValue(float64(x)), nil
+		case string:
+			f, err := strconv.ParseFloat(x, 64)
+			return parquet.DoubleValue(f), err
+		}
+	case kindBool:
+		if x, ok := v.(bool); ok {
+			return parquet.BooleanValue(x), nil
+		}
+	case kindTime:
+		if x, ok := v.(time.Time); ok {
+			return parquet.Int64Value(x.UnixMicro()), nil
+		}
+	case kindUUID:
+		id, err := uuid.Parse(formatText(v))
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		return parquet.FixedLenByteArrayValue(id[:]), nil
+	case kindBytes:
+		if x, ok := v.([]byte); ok {
+			return parquet.ByteArrayValue(x), nil
+		}
+	default:
+		return parquet.ByteArrayValue([]byte(formatText(v))), nil
+	}
+	return parquet.Value{}, fmt.Errorf("значение %v (%T) не подходит для типа колонки", v, v)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// rowWriter пишет нормализованные строки в выходной формат
+type rowWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer, cols []column) (rowWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w, cols)
+	case "jsonl":
+		return newJSONLWriter(w, cols), nil
+	case "parquet":
+		return newParquetWriter(w, cols)
+	}
+	return nil, fmt.Errorf("неизвестный формат %q (csv, jsonl, parquet)", format)
+}
+
+// formatText — текстовое представление значения для CSV
+func formatText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case json.RawMessage:
+		return string(x)
+	case []byte:
+		// Как bytea в PostgreSQL: \x + hex
+		return `\x` + hex.EncodeToString(x)
+	}
+	return fmt.Sprint(v)
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, cols []column) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter пишет по одному JSON-объекту на строку, сохраняя порядок колонок
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func newJSONLWriter(w io.Writer, cols []column) *jsonlWriter {
+	keys := make([][]byte, len(cols))
+	for i, c := range cols {
+		keys[i], _ = json.Marshal(c.Name)
+	}
+	return &jsonlWriter{w: bufio.NewWriter(w), keys: keys}
+}
+
+func (j *jsonlWriter) WriteRow(values []any) error {
+	j.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteByte(':')
+
+		// time.Time — RFC 3339, []byte — base64, JSONB вставляется как есть
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("колонка %s: %w", j.keys[i], err)
+		}
+		j.w.Write(raw)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/akozadaev/go_db_20/17_gorm_rbac/models"
+)
+
+// Права ролей для демонстрации
+var rolePermissions = map[string][]string{
+	"user":  {"profile:read", "profile:update"},
+	"admin": {"profile:read", "profile:update", "accounts:read", "accounts:delete"},
+}
+
+func main() {
+	dsn := flag.String("dsn", "rbac.db", "путь к файлу SQLite или postgres://... (схема из 11_pgx_conn_pool_migration_goose)")
+	debug := flag.Bool("debug", false, "печатать SQL")
+	flag.Parse()
+
+	db, err := openDB(*dsn)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if *debug {
+		db = db.Debug()
+	}
+	ctx := context.Background()
+	db = db.WithContext(ctx)
+
+	if err := prepareSchema(db); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// 1. Роли и права
+	if err := seedRoles(db); err != nil {
+		log.Fatalf("❌ Ошибка заполнения ролей: %v", err)
+	}
+
+	// 2. Аккаунты с ролями и сессией — одна транзакция на аккаунт
+	if err := createAccount(db, "alice_gorm", "alice.gorm@example.com", "admin"); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := createAccount(db, "bob_gorm", "bob.gorm@example.com", "user"); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// 3. BeforeCreate вызывает Validate — некорректный аккаунт не попадает в базу
+	err = db.Create(&models.Account{Username: "x", Email: "not-an-email"}).Error
+	fmt.Printf("Создание некорректного аккаунта: %v\n", err)
+
+	// 4. Загрузка ролей с правами через Preload
+	if err := printAccounts(db); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// 5. Мягкое удаление: Delete ставит is_active = false
+	if err := deactivate(db, "bob_gorm"); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := printAccounts(db); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	var all []models.Account
+	if err := db.Unscoped().Order("username").Find(&all).Error; err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println("\nВсе аккаунты, включая деактивированные (Unscoped):")
+	for _, a := range all {
+		fmt.Printf("  %s (активен: %v)\n", a.Username, a.IsActive)
+	}
+
+	// 6. Восстановление — только через Unscoped, обычный Update неактивные строки не видит
+	err = db.Unscoped().Model(&models.Account{}).
+		Where("username = ?", "bob_gorm").
+		Update("is_active", true).Error
+	if err != nil {
+		log.Fatalf("❌ Ошибка восстановления: %v", err)
+	}
+	fmt.Println("\nbob_gorm снова активен")
+}
+
+// openDB выбирает драйвер по DSN: postgres://... или host=... — Postgres, иначе файл SQLite
+func openDB(dsn string) (*gorm.DB, error) {
+	// ErrRecordNotFound из First — ожидаемый исход поиска, не шумим им в логе
+	cfg := &gorm.Config{Logger: logger.New(log.New(os.Stderr, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  true,
+	})}
+
+	var dialector gorm.Dialector
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") || strings.Contains(dsn, "host=") {
+		dialector = postgres.Open(dsn)
+	} else {
+		// Без _foreign_keys SQLite не проверяет REFERENCES и не выполняет ON DELETE CASCADE
+		if !strings.Contains(dsn, "_foreign_keys") {
+			sep := "?"
+			if strings.Contains(dsn, "?") {
+				sep = "&"
+			}
+			dsn += sep + "_foreign_keys=on"
+		}
+		dialector = sqlite.Open(dsn)
+	}
+
+	db, err := gorm.Open(dialector, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться: %w", err)
+	}
+	return db, nil
+}
+
+// prepareSchema: на SQLite таблицы создаёт AutoMigrate,
+// на Postgres схема принадлежит миграциям goose — только проверяем, что они применены
+func prepareSchema(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		if err := db.AutoMigrate(models.All()...); err != nil {
+			return fmt.Errorf("ошибка AutoMigrate: %w", err)
+		}
+		return nil
+	}
+
+	for _, m := range models.All() {
+		if !db.Migrator().HasTable(m) {
+			return fmt.Errorf("таблица для %T не найдена — примените миграции из 11_pgx_conn_pool_migration_goose", m)
+		}
+	}
+	return nil
+}
+
+func seedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permNames := range rolePermissions {
+			role := models.Role{Name: roleName}
+			if err := tx.Where(&role).FirstOrCreate(&role).Error; err != nil {
+				return fmt.Errorf("роль %s: %w", roleName, err)
+			}
+
+			perms := make([]models.Permission, 0, len(permNames))
+			for _, name := range permNames {
+				p := models.Permission{Name: name}
+				if err := tx.Where(&p).FirstOrCreate(&p).Error; err != nil {
+					return fmt.Errorf("право %s: %w", name, err)
+				}
+				perms = append(perms, p)
+			}
+
+			// Replace приводит role_permissions к заданному списку
+			if err := tx.Model(&role).Association("Permissions").Replace(perms); err != nil {
+				return fmt.Errorf("права роли %s: %w", roleName, err)
+			}
+		}
+		return nil
+	})
+}
+
+func createAccount(db *gorm.DB, username, email, roleName string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Unscoped: деактивированный аккаунт с тем же именем тоже занимает уникальный username
+		var existing models.Account
+		err := tx.Unscoped().Where("username = ?", username).First(&existing).Error
+		if err == nil {
+			fmt.Printf("Аккаунт %s уже существует\n", username)
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ошибка поиска аккаунта %s: %w", username, err)
+		}
+
+		var role models.Role
+		if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+			return fmt.Errorf("роль %s: %w", roleName, err)
+		}
+
+		// Create сохраняет аккаунт, затем связи: account_roles и sessions
+		acc := models.Account{
+			Username: username,
+			Email:    email,
+			Roles:    []models.Role{role},
+			Sessions: []models.Session{{
+				TokenHash: "hash_" + username,
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+			}},
+		}
+		if err := tx.Create(&acc).Error; err != nil {
+			return fmt.Errorf("ошибка создания аккаунта %s: %w", username, err)
+		}
+
+		details, err := models.MarshalDetails(map[string]any{"role": roleName})
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&models.AuditLog{AccountID: &acc.ID, Action: models.ActionLogin, Details: details}).Error; err != nil {
+			return fmt.Errorf("ошибка записи в журнал: %w", err)
+		}
+
+		fmt.Printf("✅ Создан аккаунт %s (id=%d, роль %s)\n", username, acc.ID, roleName)
+		return nil
+	})
+}
+
+func printAccounts(db *gorm.DB) error {
+	var accounts []models.Account
+	err := db.Scopes(models.WithPermissions).
+		Preload("Sessions").
+		Order("username").
+		Find(&accounts).Error
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки аккаунтов: %w", err)
+	}
+
+	fmt.Println("\nАктивные аккаунты, роли и права:")
+	for _, a := range accounts {
+		fmt.Printf("  %s <%s>, сессий: %d, может удалять аккаунты: %v\n",
+			a.Username, a.Email, len(a.Sessions), a.HasPermission("accounts:delete"))
+		for _, r := range a.Roles {
+			names := make([]string, 0, len(r.Permissions))
+			for _, p := range r.Permissions {
+				names = append(names, p.Name)
+			}
+			fmt.Printf("    роль %s: %s\n", r.Name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// deactivate — мягкое удаление аккаунта с записью в журнал
+func deactivate(db *gorm.DB, username string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var acc models.Account
+		if err := tx.Where("username = ?", username).First(&acc).Error; err != nil {
+			return fmt.Errorf("аккаунт %s: %w", username, err)
+		}
+
+		// UPDATE accounts SET is_active = false WHERE id = ? AND is_active = true
+		res := tx.Delete(&acc)
+		if res.Error != nil {
+			return fmt.Errorf("ошибка деактивации %s: %w", username, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("аккаунт %s уже деактивирован", username)
+		}
+
+		if err := tx.Create(&models.AuditLog{AccountID: &acc.ID, Action: models.ActionDeleteAccount}).Error; err != nil {
+			return fmt.Errorf("ошибка записи в журнал: %w", err)
+		}
+
+		fmt.Printf("\n🗑 Аккаунт %s деактивирован\n", username)
+		return nil
+	})
+}
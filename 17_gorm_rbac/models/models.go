@@ -0,0 +1,117 @@
+// Package models — GORM-модели схемы RBAC из 11_pgx_conn_pool_migration_goose.
+// Имена таблиц, колонок и связующих таблиц совпадают с миграциями,
+// поэтому модели работают и с базой, созданной goose, и с AutoMigrate на SQLite.
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/akozadaev/go_db_20/10_pgx_conn_pool_migration/account"
+)
+
+// Действия журнала — те же, что в CHECK таблицы audit_logs
+const (
+	ActionLogin         = "login"
+	ActionLogout        = "logout"
+	ActionUpdateProfile = "update_profile"
+	ActionDeleteAccount = "delete_account"
+)
+
+// ErrInvalidAction — действие не входит в список, разрешённый CHECK в audit_logs
+var ErrInvalidAction = errors.New("недопустимое действие журнала")
+
+// ID — size:32, чтобы на Postgres получались SERIAL / INTEGER, как в миграциях
+type Account struct {
+	ID        int       `gorm:"primaryKey;size:32"`
+	Username  string    `gorm:"size:50;not null;uniqueIndex;check:chk_accounts_username,length(username) >= 3"`
+	Email     string    `gorm:"size:100;not null;uniqueIndex"`
+	IsActive  Active    `gorm:"not null;default:true"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null"`
+
+	Roles    []Role    `gorm:"many2many:account_roles;constraint:OnDelete:CASCADE"`
+	Sessions []Session `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type Role struct {
+	ID          int          `gorm:"primaryKey;size:32"`
+	Name        string       `gorm:"size:50;not null;uniqueIndex;check:chk_roles_name,name <> ''"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+}
+
+type Permission struct {
+	ID   int    `gorm:"primaryKey;size:32"`
+	Name string `gorm:"size:50;not null;uniqueIndex;check:chk_permissions_name,name <> ''"`
+}
+
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	AccountID int       `gorm:"size:32;not null"`
+	TokenHash string    `gorm:"not null;check:chk_sessions_token_hash,token_hash <> ''"`
+	ExpiresAt time.Time `gorm:"type:timestamp;not null"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null"`
+
+	Account *Account
+}
+
+type AuditLog struct {
+	ID        int    `gorm:"primaryKey;size:32"`
+	AccountID *int   `gorm:"size:32;index:idx_audit_logs_account_id"`
+	Action    string `gorm:"not null;check:chk_audit_logs_action,action IN ('login', 'logout', 'update_profile', 'delete_account')"`
+	Details   JSON
+	CreatedAt time.Time `gorm:"type:timestamp;not null;index:idx_audit_logs_created_at"`
+
+	Account *Account `gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// All — модели в порядке создания таблиц, для AutoMigrate
+func All() []any {
+	return []any{&Account{}, &Role{}, &Permission{}, &Session{}, &AuditLog{}}
+}
+
+// Validate — общие правила из 10_pgx_conn_pool_migration/account: те же тексты ошибок, длина в символах
+func (a *Account) Validate() error {
+	return (&account.Account{Username: a.Username, Email: a.Email}).Validate()
+}
+
+// BeforeCreate не даёт записать некорректный аккаунт — в SQLite нет CHECK на email
+func (a *Account) BeforeCreate(*gorm.DB) error {
+	return a.Validate()
+}
+
+// WithPermissions — scope: роли аккаунта вместе с их правами (два дополнительных запроса через IN)
+func WithPermissions(db *gorm.DB) *gorm.DB {
+	return db.Preload("Roles.Permissions")
+}
+
+// HasPermission — есть ли право у аккаунта; роли с правами должны быть загружены через Preload
+func (a *Account) HasPermission(name string) bool {
+	for _, r := range a.Roles {
+		for _, p := range r.Permissions {
+			if p.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BeforeCreate генерирует UUID: DEFAULT gen_random_uuid() есть только в Postgres
+func (s *Session) BeforeCreate(*gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *AuditLog) BeforeCreate(*gorm.DB) error {
+	switch l.Action {
+	case ActionLogin, ActionLogout, ActionUpdateProfile, ActionDeleteAccount:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidAction, l.Action)
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Active — флаг is_active с поведением мягкого удаления, как у gorm.DeletedAt:
+// Find/First видят только активные строки, Delete выполняет UPDATE ... SET is_active = false,
+// Unscoped() отключает и то и другое
+type Active bool
+
+func (a *Active) Scan(value any) error {
+	// SQLite возвращает BOOLEAN как int64
+	var b sql.NullBool
+	if err := b.Scan(value); err != nil {
+		return err
+	}
+	*a = Active(b.Bool)
+	return nil
+}
+
+func (a Active) Value() (driver.Value, error) {
+	return bool(a), nil
+}
+
+func (Active) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{activeQueryClause{Field: f}}
+}
+
+func (Active) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{activeUpdateClause{Field: f}}
+}
+
+func (Active) DeleteClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{activeDeleteClause{Field: f}}
+}
+
+// activeEnabled — отметка в Statement.Clauses, что фильтр уже добавлен
+const activeEnabled = "active_filter_enabled"
+
+type activeQueryClause struct {
+	Field *schema.Field
+}
+
+func (activeQueryClause) Name() string               { return "" }
+func (activeQueryClause) Build(clause.Builder)       {}
+func (activeQueryClause) MergeClause(*clause.Clause) {}
+
+func (c activeQueryClause) ModifyStatement(stmt *gorm.Statement) {
+	if _, ok := stmt.Clauses[activeEnabled]; ok || stmt.Unscoped {
+		return
+	}
+
+	// Одиночный Or(...) без скобок превратил бы "a OR b AND is_active" в "a OR (b AND is_active)"
+	if cl, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := cl.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, expr := range where.Exprs {
+				if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					cl.Expression = where
+					stmt.Clauses["WHERE"] = cl
+					break
+				}
+			}
+		}
+	}
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: c.Field.DBName}, Value: true},
+	}})
+	stmt.Clauses[activeEnabled] = clause.Clause{}
+}
+
+type activeUpdateClause struct {
+	Field *schema.Field
+}
+
+func (activeUpdateClause) Name() string               { return "" }
+func (activeUpdateClause) Build(clause.Builder)       {}
+func (activeUpdateClause) MergeClause(*clause.Clause) {}
+
+// Обновления не затрагивают удалённые строки; восстановление — через Unscoped()
+func (c activeUpdateClause) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.SQL.Len() == 0 && !stmt.Unscoped {
+		activeQueryClause(c).ModifyStatement(stmt)
+	}
+}
+
+type activeDeleteClause struct {
+	Field *schema.Field
+}
+
+func (activeDeleteClause) Name() string               { return "" }
+func (activeDeleteClause) Build(clause.Builder)       {}
+func (activeDeleteClause) MergeClause(*clause.Clause) {}
+
+// ModifyStatement подменяет DELETE на UPDATE; повторяет логику gorm.SoftDeleteDeleteClause
+func (c activeDeleteClause) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.SQL.Len() != 0 || stmt.Unscoped {
+		return
+	}
+
+	stmt.AddClause(clause.Set{{Column: clause.Column{Name: c.Field.DBName}, Value: false}})
+	stmt.SetColumn(c.Field.DBName, Active(false), true)
+
+	if stmt.Schema != nil {
+		_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		}
+
+		if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+			_, queryValues = schema.GetIdentityFieldValuesMap(stmt.Context, reflect.ValueOf(stmt.Model), stmt.Schema.PrimaryFields)
+			column, values = schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+			if len(values) > 0 {
+				stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+			}
+		}
+	}
+
+	activeQueryClause(c).ModifyStatement(stmt)
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.Build(stmt.DB.Callback().Update().Clauses...)
+}
+
+// JSON — колонка details: JSONB в Postgres, JSON (текст) в SQLite
+type JSON json.RawMessage
+
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSON) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("JSON: неподдерживаемый тип %T", value)
+	}
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+func (JSON) GormDataType() string {
+	return "json"
+}
+
+func (JSON) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "JSONB"
+	}
+	return "JSON"
+}
+
+// MarshalDetails — удобный конструктор для AuditLog.Details
+func MarshalDetails(v any) (JSON, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации details: %w", err)
+	}
+	return JSON(b), nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/akozadaev/go_db_20/17_gorm_rbac/models"
+	"github.com/akozadaev/go_db_20/18_schema_diff/schemadiff"
+)
+
+// errDrift — расхождения найдены; отчёт уже напечатан, main только выставляет код выхода
+var errDrift = errors.New("схема расходится")
+
+type diffOptions struct {
+	dsn        string
+	schema     string
+	from       string
+	migrations string
+	out        string
+}
+
+func runDiff(ctx context.Context, args []string) error {
+	var opts diffOptions
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.StringVar(&opts.dsn, "dsn", defaultDSN, "postgres://... или путь к файлу SQLite")
+	fs.StringVar(&opts.schema, "schema", "public", "схема PostgreSQL")
+	fs.StringVar(&opts.from, "from", "migrations", "с чем сравнивать: migrations или models (17_gorm_rbac)")
+	fs.StringVar(&opts.migrations, "migrations", "../11_pgx_conn_pool_migration_goose/migrations", "каталог SQL-миграций")
+	fs.StringVar(&opts.out, "out", "", "записать черновик миграции goose в файл, - для stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if opts.from != "migrations" && opts.from != "models" {
+		return fmt.Errorf("неизвестный источник %q (migrations, models)", opts.from)
+	}
+
+	live, d, err := openDB(opts.dsn)
+	if err != nil {
+		return err
+	}
+	defer live.Close()
+
+	got, err := schemadiff.Inspect(ctx, live, d, opts.schema)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы базы: %w", err)
+	}
+	want, err := expectedSchema(ctx, live, d, opts)
+	if err != nil {
+		return err
+	}
+
+	diff := schemadiff.Compare(want, got)
+	fmt.Printf("Ожидаемая схема: %s, база: %s\n\n", describeSource(opts), d)
+	diff.WriteReport(os.Stdout)
+
+	if opts.out != "" && !diff.Empty() {
+		if err := writeMigration(opts.out, diff.Migration(d)); err != nil {
+			return err
+		}
+	}
+	if !diff.Empty() {
+		return errDrift
+	}
+	return nil
+}
+
+func describeSource(opts diffOptions) string {
+	if opts.from == "models" {
+		return "GORM-модели 17_gorm_rbac"
+	}
+	return "миграции " + opts.migrations
+}
+
+// openDB выбирает драйвер по DSN: postgres://... или host=... — pgx, иначе файл SQLite
+func openDB(dsn string) (*sql.DB, schemadiff.Dialect, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") || strings.Contains(dsn, "host=") {
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка парсинга DSN: %w", err)
+		}
+		return stdlib.OpenDB(*cfg), schemadiff.Postgres, nil
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("не удалось открыть SQLite: %w", err)
+	}
+	return db, schemadiff.SQLite, nil
+}
+
+// expectedSchema применяет миграции или AutoMigrate в «песочнице» и читает получившуюся схему:
+// на PostgreSQL — во временной схеме той же базы (её удаляем), на SQLite — в базе в памяти
+func expectedSchema(ctx context.Context, live *sql.DB, d schemadiff.Dialect, opts diffOptions) (*schemadiff.Schema, error) {
+	if d == schemadiff.SQLite {
+		if opts.from == "migrations" {
+			return nil, errors.New("миграции написаны для PostgreSQL; для SQLite используйте -from models")
+		}
+		return modelsSchemaSQLite(ctx)
+	}
+
+	scratch := fmt.Sprintf("schema_diff_%d", time.Now().UnixNano())
+	//sqlvet:ignore имя схемы генерируется здесь же, пользовательского ввода нет
+	if _, err := live.ExecContext(ctx, "CREATE SCHEMA "+scratch); err != nil {
+		return nil, fmt.Errorf("не удалось создать временную схему: %w", err)
+	}
+	//sqlvet:ignore то же имя временной схемы
+	defer live.ExecContext(context.WithoutCancel(ctx), "DROP SCHEMA "+scratch+" CASCADE")
+
+	// Все соединения песочницы работают с search_path = временная схема,
+	// поэтому неквалифицированные CREATE TABLE из миграций попадают туда
+	cfg, err := pgx.ParseConfig(opts.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DSN: %w", err)
+	}
+	cfg.RuntimeParams["search_path"] = scratch
+	sandbox := stdlib.OpenDB(*cfg)
+	defer sandbox.Close()
+
+	if opts.from == "models" {
+		gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: sandbox}), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			return nil, err
+		}
+		if err := gdb.WithContext(ctx).AutoMigrate(models.All()...); err != nil {
+			return nil, fmt.Errorf("ошибка AutoMigrate: %w", err)
+		}
+	} else if err := applyMigrations(ctx, sandbox, opts.migrations); err != nil {
+		return nil, err
+	}
+
+	return schemadiff.Inspect(ctx, sandbox, schemadiff.Postgres, scratch)
+}
+
+func modelsSchemaSQLite(ctx context.Context) (*schemadiff.Schema, error) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+	// У каждого соединения своя база в памяти — держим одно
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := gdb.WithContext(ctx).AutoMigrate(models.All()...); err != nil {
+		return nil, fmt.Errorf("ошибка AutoMigrate: %w", err)
+	}
+	return schemadiff.Inspect(ctx, sqlDB, schemadiff.SQLite, "")
+}
+
+// applyMigrations: файлы с аннотациями goose (11) применяются через goose,
+// простые SQL-файлы (10) — целиком по порядку имён
+func applyMigrations(ctx context.Context, db *sql.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("в каталоге %s нет SQL-миграций", dir)
+	}
+	sort.Strings(files)
+
+	first, err := os.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(first), "+goose Up") {
+		provider, err := goose.NewProvider(goose.DialectPostgres, db, os.DirFS(dir))
+		if err != nil {
+			return fmt.Errorf("ошибка инициализации goose: %w", err)
+		}
+		if _, err := provider.Up(ctx); err != nil {
+			return fmt.Errorf("ошибка применения миграций: %w", err)
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(content)); err != nil {
+			return fmt.Errorf("ошибка применения %s: %w", filepath.Base(f), err)
+		}
+	}
+	return nil
+}
+
+func writeMigration(path, content string) error {
+	if path == "-" {
+		fmt.Print("\n" + content)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("не удалось записать миграцию: %w", err)
+	}
+	fmt.Printf("\nЧерновик миграции: %s\n", path)
+	return nil
+}
@@ -0,0 +1,34 @@
+module github.com/akozadaev/go_db_20/18_schema_diff
+
+go 1.25.1
+
+require (
+	github.com/akozadaev/go_db_20/17_gorm_rbac v0.0.0
+	github.com/jackc/pgx/v5 v5.7.6
+	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/pressly/goose/v3 v3.26.0
+	gorm.io/driver/postgres v1.6.0
+	gorm.io/driver/sqlite v1.6.0
+	gorm.io/gorm v1.31.0
+)
+
+require (
+	github.com/akozadaev/go_db_20/10_pgx_conn_pool_migration v0.0.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/mfridman/interpolate v0.0.2 // indirect
+	github.com/sethvargo/go-retry v0.3.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/crypto v0.40.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
+)
+
+replace (
+	github.com/akozadaev/go_db_20/10_pgx_conn_pool_migration => ../10_pgx_conn_pool_migration
+	github.com/akozadaev/go_db_20/17_gorm_rbac => ../17_gorm_rbac
+)
@@ -0,0 +1,291 @@
+package schemadiff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Inspect читает таблицы, колонки, индексы и ограничения.
+// schemaName учитывается только для PostgreSQL (обычно "public").
+func Inspect(ctx context.Context, db *sql.DB, d Dialect, schemaName string) (*Schema, error) {
+	switch d {
+	case Postgres:
+		return inspectPostgres(ctx, db, schemaName)
+	case SQLite:
+		return inspectSQLite(ctx, db)
+	}
+	return nil, fmt.Errorf("неизвестный диалект %q", d)
+}
+
+func inspectPostgres(ctx context.Context, db *sql.DB, schemaName string) (*Schema, error) {
+	s := newSchema(Postgres)
+
+	// Таблицы — через information_schema, детали — через pg_catalog:
+	// там есть точные типы с модификаторами и определения ограничений
+	err := queryEach(ctx, db, `
+		SELECT table_name::text
+		FROM information_schema.tables
+		WHERE table_schema = $1 AND table_type = 'BASE TABLE'`,
+		[]any{schemaName},
+		func(rows *sql.Rows) error {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			s.table(name)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения таблиц: %w", err)
+	}
+
+	err = queryEach(ctx, db, `
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+		       COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`,
+		[]any{schemaName},
+		func(rows *sql.Rows) error {
+			var table string
+			var col Column
+			if err := rows.Scan(&table, &col.Name, &col.Type, &col.NotNull, &col.Default); err != nil {
+				return err
+			}
+			t := s.table(table)
+			t.Columns = append(t.Columns, col)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения колонок: %w", err)
+	}
+
+	// pg_get_indexdef(oid, k, true) даёт k-ю колонку индекса, в том числе выражение
+	err = queryEach(ctx, db, `
+		SELECT t.relname, i.relname, ix.indisunique,
+		       ARRAY_TO_STRING(ARRAY(
+		           SELECT pg_get_indexdef(ix.indexrelid, k, true)
+		           FROM generate_series(1, ix.indnkeyatts) AS k
+		           ORDER BY k
+		       ), ',')
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1 AND NOT ix.indisprimary`,
+		[]any{schemaName},
+		func(rows *sql.Rows) error {
+			var table, cols string
+			var idx Index
+			if err := rows.Scan(&table, &idx.Name, &idx.Unique, &cols); err != nil {
+				return err
+			}
+			idx.Columns = strings.Split(cols, ",")
+			t := s.table(table)
+			t.Indexes = append(t.Indexes, idx)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения индексов: %w", err)
+	}
+
+	// UNIQUE-ограничения уже учтены как уникальные индексы, NOT NULL — в колонках
+	err = queryEach(ctx, db, `
+		SELECT c.relname, con.conname, con.contype::text, pg_get_constraintdef(con.oid, true)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND con.contype IN ('p', 'f', 'c')`,
+		[]any{schemaName},
+		func(rows *sql.Rows) error {
+			var table, contype string
+			var con Constraint
+			if err := rows.Scan(&table, &con.Name, &contype, &con.Def); err != nil {
+				return err
+			}
+			switch contype {
+			case "p":
+				con.Kind = PrimaryKey
+			case "f":
+				con.Kind = ForeignKey
+			case "c":
+				con.Kind = Check
+			}
+			t := s.table(table)
+			t.Constraints = append(t.Constraints, con)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ограничений: %w", err)
+	}
+
+	for _, t := range s.Tables {
+		sortTable(t)
+	}
+	return s, nil
+}
+
+// inspectSQLite читает схему через sqlite_master и pragma-функции.
+// CHECK-ограничения SQLite хранит только в тексте CREATE TABLE, поэтому они не сравниваются.
+func inspectSQLite(ctx context.Context, db *sql.DB) (*Schema, error) {
+	s := newSchema(SQLite)
+
+	var tables []string
+	err := queryEach(ctx, db, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`,
+		nil,
+		func(rows *sql.Rows) error {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			tables = append(tables, name)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения таблиц: %w", err)
+	}
+
+	for _, name := range tables {
+		t := s.table(name)
+		if err := inspectSQLiteTable(ctx, db, t); err != nil {
+			return nil, fmt.Errorf("таблица %s: %w", name, err)
+		}
+		sortTable(t)
+	}
+	return s, nil
+}
+
+func inspectSQLiteTable(ctx context.Context, db *sql.DB, t *Table) error {
+	var pk []string
+	pkPos := map[string]int{}
+	err := queryEach(ctx, db,
+		`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?) ORDER BY cid`,
+		[]any{t.Name},
+		func(rows *sql.Rows) error {
+			var col Column
+			var pos int
+			if err := rows.Scan(&col.Name, &col.Type, &col.NotNull, &col.Default, &pos); err != nil {
+				return err
+			}
+			col.Type = strings.ToLower(col.Type)
+			if pos > 0 {
+				pk = append(pk, col.Name)
+				pkPos[col.Name] = pos
+			}
+			t.Columns = append(t.Columns, col)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения колонок: %w", err)
+	}
+	if len(pk) > 0 {
+		// pk в pragma_table_info — позиция колонки в составном ключе
+		slices.SortFunc(pk, func(a, b string) int { return pkPos[a] - pkPos[b] })
+		t.Constraints = append(t.Constraints, Constraint{
+			Name: t.Name + "_pkey",
+			Kind: PrimaryKey,
+			Def:  "PRIMARY KEY (" + strings.Join(pk, ", ") + ")",
+		})
+	}
+
+	// Внешние ключи: составной ключ — несколько строк с одним id
+	type fk struct {
+		table, onDelete string
+		from, to        []string
+	}
+	var fks []*fk
+	byID := map[int]*fk{}
+	err = queryEach(ctx, db,
+		`SELECT id, "table", "from", COALESCE("to", ''), on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`,
+		[]any{t.Name},
+		func(rows *sql.Rows) error {
+			var id int
+			var refTable, from, to, onDelete string
+			if err := rows.Scan(&id, &refTable, &from, &to, &onDelete); err != nil {
+				return err
+			}
+			f, ok := byID[id]
+			if !ok {
+				f = &fk{table: refTable, onDelete: onDelete}
+				byID[id] = f
+				fks = append(fks, f)
+			}
+			f.from = append(f.from, from)
+			f.to = append(f.to, to)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения внешних ключей: %w", err)
+	}
+	for _, f := range fks {
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)",
+			strings.Join(f.from, ", "), f.table, strings.Join(f.to, ", "))
+		if f.onDelete != "" && f.onDelete != "NO ACTION" {
+			def += " ON DELETE " + f.onDelete
+		}
+		t.Constraints = append(t.Constraints, Constraint{
+			Name: fmt.Sprintf("%s_%s_fkey", t.Name, strings.Join(f.from, "_")),
+			Kind: ForeignKey,
+			Def:  def,
+		})
+	}
+
+	// origin: c — CREATE INDEX, u — UNIQUE в таблице, pk — первичный ключ (уже учтён)
+	var indexes []Index
+	err = queryEach(ctx, db,
+		`SELECT name, "unique" FROM pragma_index_list(?) WHERE origin <> 'pk'`,
+		[]any{t.Name},
+		func(rows *sql.Rows) error {
+			var idx Index
+			if err := rows.Scan(&idx.Name, &idx.Unique); err != nil {
+				return err
+			}
+			indexes = append(indexes, idx)
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения индексов: %w", err)
+	}
+	for _, idx := range indexes {
+		err = queryEach(ctx, db,
+			`SELECT COALESCE(name, '<expr>') FROM pragma_index_info(?) ORDER BY seqno`,
+			[]any{idx.Name},
+			func(rows *sql.Rows) error {
+				var col string
+				if err := rows.Scan(&col); err != nil {
+					return err
+				}
+				idx.Columns = append(idx.Columns, col)
+				return nil
+			})
+		if err != nil {
+			return fmt.Errorf("ошибка чтения индекса %s: %w", idx.Name, err)
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+	return nil
+}
+
+func queryEach(ctx context.Context, db *sql.DB, query string, args []any, fn func(*sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
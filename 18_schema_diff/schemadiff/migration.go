@@ -0,0 +1,197 @@
+package schemadiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var referencesRe = regexp.MustCompile(`(?i)REFERENCES\s+"?([\w.]+)"?\s*\(`)
+
+// Migration — черновик миграции goose, который приводит базу к ожидаемой схеме.
+// Добавления генерируются как SQL, удаления лишнего и то, что диалект не умеет, — комментариями:
+// такие шаги теряют данные или требуют пересоздания таблицы, решать их должен человек.
+func (d *Diff) Migration(dialect Dialect) string {
+	var up, down []string
+
+	for _, t := range orderByReferences(d.MissingTables) {
+		up = append(up, createTable(t, dialect)...)
+		down = append(down, "DROP TABLE IF EXISTS "+t.Name+";")
+	}
+	for _, t := range d.ExtraTables {
+		up = append(up, fmt.Sprintf("-- DROP TABLE %s; -- есть в базе, но не ожидается", t.Name))
+	}
+
+	for _, td := range d.Tables {
+		for _, c := range td.MissingColumns {
+			up = append(up, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", td.Name, columnDef(c, dialect)))
+			down = append(down, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", td.Name, c.Name))
+		}
+		for _, c := range td.ExtraColumns {
+			up = append(up, fmt.Sprintf("-- ALTER TABLE %s DROP COLUMN %s; -- есть в базе, но не ожидается", td.Name, c.Name))
+		}
+		for _, ch := range td.ChangedColumns {
+			u, dn := alterColumn(td.Name, ch, dialect)
+			up = append(up, u...)
+			down = append(down, dn...)
+		}
+
+		for _, i := range td.MissingIndexes {
+			up = append(up, createIndex(td.Name, i))
+			down = append(down, "DROP INDEX IF EXISTS "+i.Name+";")
+		}
+		for _, i := range td.ExtraIndexes {
+			up = append(up, fmt.Sprintf("-- DROP INDEX %s; -- есть в базе, но не ожидается", i.Name))
+		}
+
+		for _, c := range td.MissingConstraints {
+			if dialect == SQLite {
+				up = append(up, fmt.Sprintf("-- SQLite не умеет ADD CONSTRAINT, пересоздайте таблицу %s: %s", td.Name, c.Def))
+				continue
+			}
+			up = append(up, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", td.Name, c.Name, c.Def))
+			down = append(down, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", td.Name, c.Name))
+		}
+		for _, c := range td.ExtraConstraints {
+			up = append(up, fmt.Sprintf("-- ALTER TABLE %s DROP CONSTRAINT %s; -- есть в базе, но не ожидается: %s", td.Name, c.Name, c.Def))
+		}
+	}
+
+	// Откат — в обратном порядке
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("-- Черновик миграции от schema_diff: проверьте каждую строку перед применением\n")
+	writeSection(&sb, "Up", up)
+	sb.WriteString("\n")
+	writeSection(&sb, "Down", down)
+	return sb.String()
+}
+
+func writeSection(sb *strings.Builder, name string, stmts []string) {
+	sb.WriteString("-- +goose " + name + "\n")
+	sb.WriteString("-- +goose StatementBegin\n")
+	if len(stmts) == 0 {
+		sb.WriteString("SELECT 1;\n")
+	}
+	for _, s := range stmts {
+		sb.WriteString(s + "\n")
+	}
+	sb.WriteString("-- +goose StatementEnd\n")
+}
+
+func createTable(t *Table, dialect Dialect) []string {
+	lines := make([]string, 0, len(t.Columns)+len(t.Constraints))
+	for _, c := range t.Columns {
+		lines = append(lines, "    "+columnDef(c, dialect))
+	}
+	for _, c := range t.Constraints {
+		if dialect == SQLite {
+			// Имена ограничений SQLite придуманы при чтении схемы, в DDL они не нужны
+			lines = append(lines, "    "+c.Def)
+			continue
+		}
+		lines = append(lines, "    CONSTRAINT "+c.Name+" "+c.Def)
+	}
+
+	stmts := []string{"CREATE TABLE " + t.Name + " (\n" + strings.Join(lines, ",\n") + "\n);"}
+	for _, i := range t.Indexes {
+		stmts = append(stmts, createIndex(t.Name, i))
+	}
+	return stmts
+}
+
+func createIndex(table string, i Index) string {
+	unique := ""
+	if i.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, i.Name, table, strings.Join(i.Columns, ", "))
+}
+
+// columnDef — определение колонки для CREATE TABLE / ADD COLUMN.
+// DEFAULT nextval('..._seq') в PostgreSQL превращаем обратно в SERIAL: последовательности ещё нет.
+func columnDef(c Column, dialect Dialect) string {
+	if dialect == Postgres && strings.HasPrefix(c.Default, "nextval(") {
+		switch c.Type {
+		case "smallint":
+			return c.Name + " smallserial NOT NULL"
+		case "integer":
+			return c.Name + " serial NOT NULL"
+		case "bigint":
+			return c.Name + " bigserial NOT NULL"
+		}
+	}
+	return c.String()
+}
+
+func alterColumn(table string, ch ColumnChange, dialect Dialect) (up, down []string) {
+	if dialect == SQLite {
+		up = append(up, fmt.Sprintf("-- SQLite не умеет ALTER COLUMN, пересоздайте таблицу %s: ожидается %s, в базе %s", table, ch.Want, ch.Got))
+		return up, nil
+	}
+
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", table, ch.Want.Name)
+	if ch.Want.Type != ch.Got.Type {
+		up = append(up, prefix+"TYPE "+ch.Want.Type+";")
+		down = append(down, prefix+"TYPE "+ch.Got.Type+";")
+	}
+	if ch.Want.NotNull != ch.Got.NotNull {
+		up = append(up, prefix+notNullAction(ch.Want.NotNull)+";")
+		down = append(down, prefix+notNullAction(ch.Got.NotNull)+";")
+	}
+	if ch.Want.Default != ch.Got.Default {
+		up = append(up, prefix+defaultAction(ch.Want.Default)+";")
+		down = append(down, prefix+defaultAction(ch.Got.Default)+";")
+	}
+	return up, down
+}
+
+func notNullAction(notNull bool) string {
+	if notNull {
+		return "SET NOT NULL"
+	}
+	return "DROP NOT NULL"
+}
+
+func defaultAction(def string) string {
+	if def == "" {
+		return "DROP DEFAULT"
+	}
+	return "SET DEFAULT " + def
+}
+
+// orderByReferences ставит таблицы так, чтобы таблица создавалась после тех, на которые ссылается
+func orderByReferences(tables []*Table) []*Table {
+	byName := make(map[string]*Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+
+	ordered := make([]*Table, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+	var visit func(t *Table)
+	visit = func(t *Table) {
+		if visited[t.Name] {
+			return
+		}
+		visited[t.Name] = true
+		for _, c := range t.Constraints {
+			if c.Kind != ForeignKey {
+				continue
+			}
+			if m := referencesRe.FindStringSubmatch(c.Def); m != nil {
+				if dep, ok := byName[m[1]]; ok {
+					visit(dep)
+				}
+			}
+		}
+		ordered = append(ordered, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return ordered
+}
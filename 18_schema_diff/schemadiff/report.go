@@ -0,0 +1,47 @@
+package schemadiff
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteReport печатает расхождения в читаемом виде:
+// "-" — ожидается, но нет в базе; "+" — есть в базе, но не ожидается; "~" — отличается
+func (d *Diff) WriteReport(w io.Writer) {
+	if d.Empty() {
+		fmt.Fprintln(w, "Расхождений нет")
+		return
+	}
+
+	for _, t := range d.MissingTables {
+		fmt.Fprintf(w, "- таблица %s\n", t.Name)
+	}
+	for _, t := range d.ExtraTables {
+		fmt.Fprintf(w, "+ таблица %s\n", t.Name)
+	}
+
+	for _, td := range d.Tables {
+		fmt.Fprintf(w, "Таблица %s:\n", td.Name)
+		for _, c := range td.MissingColumns {
+			fmt.Fprintf(w, "  - колонка %s\n", c)
+		}
+		for _, c := range td.ExtraColumns {
+			fmt.Fprintf(w, "  + колонка %s\n", c)
+		}
+		for _, ch := range td.ChangedColumns {
+			fmt.Fprintf(w, "  ~ колонка %s: ожидается %q, в базе %q\n", ch.Want.Name, ch.Want, ch.Got)
+		}
+		for _, i := range td.MissingIndexes {
+			fmt.Fprintf(w, "  - %s\n", i)
+		}
+		for _, i := range td.ExtraIndexes {
+			fmt.Fprintf(w, "  + %s\n", i)
+		}
+		for _, c := range td.MissingConstraints {
+			fmt.Fprintf(w, "  - ограничение %s\n", c)
+		}
+		for _, c := range td.ExtraConstraints {
+			fmt.Fprintf(w, "  + ограничение %s\n", c)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package schemadiff читает схему живой базы (PostgreSQL или SQLite)
+// и сравнивает её с ожидаемой: полученной из файлов миграций или из GORM-моделей.
+package schemadiff
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Служебные таблицы мигратора и SQLite в сравнении не участвуют
+var ignoredTables = map[string]bool{
+	"goose_db_version": true,
+	"sqlite_sequence":  true,
+}
+
+type Schema struct {
+	Dialect Dialect
+	Tables  map[string]*Table
+}
+
+type Table struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+	// Default — выражение по умолчанию в том виде, как его показывает база; пусто, если нет
+	Default string
+}
+
+// Index — индекс, включая индексы UNIQUE-ограничений; индекс первичного ключа сюда не попадает.
+// Индексы сравниваются по колонкам и уникальности, имя на совпадение не влияет.
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+type ConstraintKind string
+
+const (
+	PrimaryKey ConstraintKind = "PRIMARY KEY"
+	ForeignKey ConstraintKind = "FOREIGN KEY"
+	Check      ConstraintKind = "CHECK"
+)
+
+// Constraint — ограничение таблицы. Def — определение без имени,
+// например "FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE";
+// по нему и сравниваем, имена у GORM и у рукописных миграций разные.
+type Constraint struct {
+	Name string
+	Kind ConstraintKind
+	Def  string
+}
+
+func newSchema(d Dialect) *Schema {
+	return &Schema{Dialect: d, Tables: make(map[string]*Table)}
+}
+
+func (s *Schema) table(name string) *Table {
+	t, ok := s.Tables[name]
+	if !ok {
+		t = &Table{Name: name}
+		s.Tables[name] = t
+	}
+	return t
+}
+
+// TableNames — имена таблиц по алфавиту
+func (s *Schema) TableNames() []string {
+	names := make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *Table) Column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func (c Column) String() string {
+	var sb strings.Builder
+	sb.WriteString(c.Name + " " + c.Type)
+	if c.NotNull {
+		sb.WriteString(" NOT NULL")
+	}
+	if c.Default != "" {
+		sb.WriteString(" DEFAULT " + c.Default)
+	}
+	return sb.String()
+}
+
+func (i Index) key() string {
+	return strings.Join(i.Columns, ",") + "|" + boolKey(i.Unique)
+}
+
+func (i Index) String() string {
+	kind := "INDEX"
+	if i.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	return kind + " " + i.Name + " (" + strings.Join(i.Columns, ", ") + ")"
+}
+
+func (c Constraint) key() string {
+	return string(c.Kind) + "|" + normalizeSpace(c.Def)
+}
+
+func (c Constraint) String() string {
+	return c.Name + " " + c.Def
+}
+
+func boolKey(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Diff — расхождения: Missing — ожидается, но нет в базе; Extra — есть в базе, но не ожидается
+type Diff struct {
+	MissingTables []*Table
+	ExtraTables   []*Table
+	Tables        []TableDiff
+}
+
+type TableDiff struct {
+	Name               string
+	MissingColumns     []Column
+	ExtraColumns       []Column
+	ChangedColumns     []ColumnChange
+	MissingIndexes     []Index
+	ExtraIndexes       []Index
+	MissingConstraints []Constraint
+	ExtraConstraints   []Constraint
+}
+
+type ColumnChange struct {
+	Want Column
+	Got  Column
+}
+
+func (td TableDiff) empty() bool {
+	return len(td.MissingColumns) == 0 && len(td.ExtraColumns) == 0 && len(td.ChangedColumns) == 0 &&
+		len(td.MissingIndexes) == 0 && len(td.ExtraIndexes) == 0 &&
+		len(td.MissingConstraints) == 0 && len(td.ExtraConstraints) == 0
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.MissingTables) == 0 && len(d.ExtraTables) == 0 && len(d.Tables) == 0
+}
+
+// Compare сравнивает ожидаемую схему want с фактической got
+func Compare(want, got *Schema) *Diff {
+	d := &Diff{}
+	for _, name := range want.TableNames() {
+		if ignoredTables[name] {
+			continue
+		}
+		wt := want.Tables[name]
+		gt, ok := got.Tables[name]
+		if !ok {
+			d.MissingTables = append(d.MissingTables, wt)
+			continue
+		}
+		if td := compareTables(wt, gt); !td.empty() {
+			d.Tables = append(d.Tables, td)
+		}
+	}
+	for _, name := range got.TableNames() {
+		if _, ok := want.Tables[name]; !ok && !ignoredTables[name] {
+			d.ExtraTables = append(d.ExtraTables, got.Tables[name])
+		}
+	}
+	return d
+}
+
+func compareTables(want, got *Table) TableDiff {
+	td := TableDiff{Name: want.Name}
+
+	for _, wc := range want.Columns {
+		gc, ok := got.Column(wc.Name)
+		switch {
+		case !ok:
+			td.MissingColumns = append(td.MissingColumns, wc)
+		case wc != gc:
+			td.ChangedColumns = append(td.ChangedColumns, ColumnChange{Want: wc, Got: gc})
+		}
+	}
+	for _, gc := range got.Columns {
+		if _, ok := want.Column(gc.Name); !ok {
+			td.ExtraColumns = append(td.ExtraColumns, gc)
+		}
+	}
+
+	td.MissingIndexes, td.ExtraIndexes = diffByKey(want.Indexes, got.Indexes, Index.key)
+	td.MissingConstraints, td.ExtraConstraints = diffByKey(want.Constraints, got.Constraints, Constraint.key)
+	return td
+}
+
+// diffByKey — элементы want без пары в got и наоборот; пары ищутся по key с учётом повторов
+func diffByKey[T any](want, got []T, key func(T) string) (missing, extra []T) {
+	left := make(map[string]int, len(got))
+	for _, g := range got {
+		left[key(g)]++
+	}
+	for _, w := range want {
+		k := key(w)
+		if left[k] > 0 {
+			left[k]--
+			continue
+		}
+		missing = append(missing, w)
+	}
+
+	need := make(map[string]int, len(want))
+	for _, w := range want {
+		need[key(w)]++
+	}
+	for _, g := range got {
+		k := key(g)
+		if need[k] > 0 {
+			need[k]--
+			continue
+		}
+		extra = append(extra, g)
+	}
+	return missing, extra
+}
+
+var kindOrder = map[ConstraintKind]int{PrimaryKey: 0, ForeignKey: 1, Check: 2}
+
+// sortTable — стабильный порядок индексов и ограничений для отчёта: ключ, внешние ключи, CHECK
+func sortTable(t *Table) {
+	slices.SortFunc(t.Indexes, func(a, b Index) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(t.Constraints, func(a, b Constraint) int {
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] - kindOrder[b.Kind]
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
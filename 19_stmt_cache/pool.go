@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newPool — пул pgx с явно выбранным кешем на стороне pgx.
+// У pgx кеш встроен в каждое соединение, вручную Prepare/Close делать не нужно:
+//
+//   - QueryExecModeCacheStatement (по умолчанию) — запрос готовится на сервере один раз
+//     на соединение, дальше только Bind/Execute. Самый быстрый, но ломается за PgBouncer
+//     в режиме transaction pooling и после ALTER TABLE, меняющего тип результата.
+//   - QueryExecModeCacheDescribe — кешируется только описание параметров и колонок,
+//     запрос выполняется как безымянный. Совместим с PgBouncer, на круг медленнее.
+//
+// capacity — размер LRU-кеша соединения (StatementCacheCapacity или DescriptionCacheCapacity).
+func newPool(ctx context.Context, dsn string, mode pgx.QueryExecMode, capacity int) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DSN: %w", err)
+	}
+
+	cfg.ConnConfig.DefaultQueryExecMode = mode
+	switch mode {
+	case pgx.QueryExecModeCacheStatement:
+		cfg.ConnConfig.StatementCacheCapacity = capacity
+	case pgx.QueryExecModeCacheDescribe:
+		cfg.ConnConfig.DescriptionCacheCapacity = capacity
+	default:
+		return nil, fmt.Errorf("режим %v не использует кеш запросов", mode)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пул: %w", err)
+	}
+	return pool, nil
+}
@@ -0,0 +1,221 @@
+// Package stmtcache — LRU-кеш подготовленных запросов database/sql по тексту SQL.
+//
+// Выдавать *sql.Stmt наружу небезопасно: его могут вытеснить и закрыть между Get и Exec.
+// Поэтому кеш сам выполняет запросы, а вытесненный запрос закрывается,
+// когда его перестают использовать.
+package stmtcache
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultSize — ёмкость кеша, если не задана
+const DefaultSize = 64
+
+var ErrClosed = errors.New("кеш подготовленных запросов закрыт")
+
+// Stats — счётчики кеша
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+	Capacity  int
+}
+
+// HitRatio — доля попаданий; 0, если запросов ещё не было
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type entry struct {
+	query string
+	stmt  *sql.Stmt
+	// refs — сколько вызовов сейчас используют stmt; закрываем только при refs == 0
+	refs    int
+	evicted bool
+	elem    *list.Element
+}
+
+// Cache — кеш поверх одного *sql.DB, безопасен для конкурентного использования
+type Cache struct {
+	db   *sql.DB
+	size int
+
+	mu      sync.Mutex
+	lru     *list.List // front — самый свежий
+	entries map[string]*entry
+	closed  bool
+	stats   Stats
+}
+
+// New создаёт кеш на size запросов; size <= 0 — DefaultSize
+func New(db *sql.DB, size int) *Cache {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Cache{
+		db:      db,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*entry, size),
+	}
+}
+
+// acquire возвращает запись с подготовленным запросом и увеличивает её refs.
+// После использования обязательно release.
+func (c *Cache) acquire(ctx context.Context, query string) (*entry, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if e, ok := c.entries[query]; ok {
+		c.stats.Hits++
+		e.refs++
+		c.lru.MoveToFront(e.elem)
+		c.mu.Unlock()
+		return e, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Prepare — сетевой вызов, не держим блокировку на время его выполнения
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подготовки запроса: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		stmt.Close()
+		return nil, ErrClosed
+	}
+	// Пока готовили, тот же запрос мог подготовить другой вызов — берём его, свой закрываем
+	if e, ok := c.entries[query]; ok {
+		stmt.Close()
+		e.refs++
+		c.lru.MoveToFront(e.elem)
+		return e, nil
+	}
+
+	e := &entry{query: query, stmt: stmt, refs: 1}
+	e.elem = c.lru.PushFront(e)
+	c.entries[query] = e
+	for c.lru.Len() > c.size {
+		c.evictLocked(c.lru.Back().Value.(*entry))
+	}
+	return e, nil
+}
+
+func (c *Cache) release(e *entry) {
+	c.mu.Lock()
+	e.refs--
+	closeNow := e.evicted && e.refs == 0
+	c.mu.Unlock()
+
+	if closeNow {
+		e.stmt.Close()
+	}
+}
+
+// evictLocked убирает запись из кеша; закрывает сразу, если она не используется
+func (c *Cache) evictLocked(e *entry) {
+	c.lru.Remove(e.elem)
+	delete(c.entries, e.query)
+	e.evicted = true
+	c.stats.Evictions++
+	if e.refs == 0 {
+		e.stmt.Close()
+	}
+}
+
+func (c *Cache) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(e)
+	return e.stmt.ExecContext(ctx, args...)
+}
+
+// QueryContext — *sql.Rows сами держат запрос открытым до rows.Close,
+// даже если его успеют вытеснить из кеша
+func (c *Cache) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	e, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(e)
+	return e.stmt.QueryContext(ctx, args...)
+}
+
+// QueryRowContext — ошибка подготовки вернётся из Scan, как у *sql.DB
+func (c *Cache) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	e, err := c.acquire(ctx, query)
+	if err != nil {
+		return &Row{err: err}
+	}
+	defer c.release(e)
+	return &Row{row: e.stmt.QueryRowContext(ctx, args...)}
+}
+
+// Row — *sql.Row с возможной ошибкой подготовки
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// Stats — снимок счётчиков
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Size = c.lru.Len()
+	s.Capacity = c.size
+	return s
+}
+
+// Close закрывает все запросы; используемые сейчас закроются по завершении.
+// *sql.DB не закрывается — им владеет вызывающий код.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	var first error
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		ent := e.Value.(*entry)
+		c.lru.Remove(e)
+		delete(c.entries, ent.query)
+		ent.evicted = true
+		if ent.refs == 0 {
+			if err := ent.stmt.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+		e = next
+	}
+	return first
+}
@@ -0,0 +1,88 @@
+package stmtcache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// TxCache — те же запросы внутри транзакции.
+// tx.StmtContext привязывает закешированный запрос к соединению транзакции:
+// на этом соединении он уже подготовлен или будет подготовлен один раз.
+// Производные запросы закрывает сама транзакция при Commit/Rollback,
+// а Close возвращает ссылки на записи основного кеша.
+type TxCache struct {
+	c     *Cache
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+	held  []*entry
+}
+
+// Tx возвращает кеш для транзакции; после Commit или Rollback вызовите Close
+func (c *Cache) Tx(tx *sql.Tx) *TxCache {
+	return &TxCache{c: c, tx: tx, stmts: make(map[string]*sql.Stmt)}
+}
+
+func (t *TxCache) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	if s, ok := t.stmts[query]; ok {
+		return s, nil
+	}
+	// Запись держим до Close: основной запрос не должен закрыться, пока им пользуется транзакция
+	e, err := t.c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	t.held = append(t.held, e)
+
+	s := t.tx.StmtContext(ctx, e.stmt)
+	t.stmts[query] = s
+	return s, nil
+}
+
+func (t *TxCache) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	s, err := t.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.ExecContext(ctx, args...)
+}
+
+func (t *TxCache) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	s, err := t.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.QueryContext(ctx, args...)
+}
+
+func (t *TxCache) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	s, err := t.stmt(ctx, query)
+	if err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: s.QueryRowContext(ctx, args...)}
+}
+
+// Close освобождает записи основного кеша; повторный вызов ничего не делает
+func (t *TxCache) Close() {
+	for _, e := range t.held {
+		t.c.release(e)
+	}
+	t.held = nil
+	t.stmts = nil
+}
+
+// WithTx выполняет fn в транзакции с кешем запросов: Commit при nil, иначе Rollback
+func (c *Cache) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(*TxCache) error) error {
+	tx, err := c.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	txc := c.Tx(tx)
+	defer txc.Close()
+
+	if err := fn(txc); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
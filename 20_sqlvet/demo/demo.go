@@ -0,0 +1,53 @@
+// Package demo — примеры для проверки sqlvet; go vet с анализатором находит здесь три проблемы
+package demo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Опасно: имя подставляется в текст запроса
+func FindByNameUnsafe(ctx context.Context, db *sql.DB, name string) (*sql.Rows, error) {
+	return db.QueryContext(ctx, "SELECT id, name FROM users WHERE name = '"+name+"'")
+}
+
+// Опасно: Sprintf не экранирует значения
+func DeleteOlderThanUnsafe(db *sql.DB, age string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM users WHERE age > %s", age))
+	return err
+}
+
+// Опасно: запрос собран заранее в переменной
+func CountUnsafe(tx *sql.Tx, table string) (int, error) {
+	q := "SELECT COUNT(*) FROM "
+	q += table
+	var n int
+	err := tx.QueryRow(q).Scan(&n)
+	return n, err
+}
+
+// Безопасно: значение передаётся параметром
+func FindByName(ctx context.Context, db *sql.DB, name string) (*sql.Rows, error) {
+	return db.QueryContext(ctx, "SELECT id, name FROM users WHERE name = ?", name)
+}
+
+// Безопасно: конкатенация констант вычисляется при компиляции
+const usersColumns = "id, name, age"
+
+func ListUsers(db *sql.DB) (*sql.Rows, error) {
+	return db.Query("SELECT " + usersColumns + " FROM users ORDER BY id")
+}
+
+// Подавлено: имя таблицы из белого списка, проверено выше
+func CountAllowed(db *sql.DB, table string) (int, error) {
+	switch table {
+	case "users", "accounts":
+	default:
+		return 0, fmt.Errorf("таблица %q не разрешена", table)
+	}
+	var n int
+	//sqlvet:ignore имя таблицы проверено по белому списку
+	err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,14 @@
+// sqlvet запускается через go vet:
+//
+//	go build -o sqlvet . && go vet -vettool=$(pwd)/sqlvet ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/akozadaev/go_db_20/20_sqlvet/sqlvet"
+)
+
+func main() {
+	unitchecker.Main(sqlvet.Analyzer)
+}
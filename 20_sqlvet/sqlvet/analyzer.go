@@ -0,0 +1,267 @@
+// Package sqlvet — анализатор go/analysis, который ищет SQL, собранный из строк:
+// конкатенация через + или fmt.Sprintf, переданная в Exec/Query/QueryRow/Prepare
+// у database/sql, sqlx, pgx, pgxpool и в Raw/Exec у GORM.
+//
+// Подавление: комментарий //sqlvet:ignore <причина> на строке вызова или строкой выше.
+// Директива без причины не действует: находка остаётся и напоминает указать причину.
+package sqlvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const ignoreDirective = "//sqlvet:ignore"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "sqlvet",
+	Doc:      "ищет SQL-запросы, собранные через + или fmt.Sprintf из непостоянных строк (риск SQL-инъекции)",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// targets — типы, у которых проверяются методы: путь пакета -> имена типов.
+// Методы встроенных типов (sqlx.DB встраивает *sql.DB) разрешаются в методы sql.DB сами.
+var targets = map[string]map[string]bool{
+	"database/sql":                    {"DB": true, "Tx": true, "Conn": true},
+	"github.com/jmoiron/sqlx":         {"DB": true, "Tx": true, "Conn": true},
+	"github.com/jackc/pgx/v5":         {"Conn": true, "Tx": true},
+	"github.com/jackc/pgx/v5/pgxpool": {"Pool": true, "Conn": true, "Tx": true},
+	"gorm.io/gorm":                    {"DB": true},
+}
+
+// Префиксы методов, принимающих текст запроса
+var methodPrefixes = []string{"Exec", "Query", "Prepare", "Raw", "Get", "Select", "Named", "MustExec"}
+
+func run(pass *analysis.Pass) (any, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	ignored := ignoredLines(pass)
+
+	nodes := []ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}
+	ins.Nodes(nodes, func(n ast.Node, push bool) bool {
+		if !push {
+			return false
+		}
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		}
+		if body == nil {
+			return false
+		}
+		checkFunc(pass, body, ignored)
+		// Вложенные FuncLit проверит checkFunc вместе с телом
+		return false
+	})
+	return nil, nil
+}
+
+// checkFunc проверяет вызовы в теле функции; присваивания переменным ищутся в том же теле
+func checkFunc(pass *analysis.Pass, body *ast.BlockStmt, ignored map[lineKey]string) {
+	assigns := collectAssigns(pass, body)
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		idx, name := sqlArgIndex(pass, call)
+		if idx < 0 || idx >= len(call.Args) {
+			return true
+		}
+		arg := call.Args[idx]
+
+		reason := unsafeReason(pass, arg, assigns)
+		if reason == "" {
+			return true
+		}
+		switch ignore, ok := ignoreReason(pass, call, ignored); {
+		case ok && ignore != "":
+			return true
+		case ok:
+			pass.Reportf(arg.Pos(), "SQL для %s %s: у //sqlvet:ignore нет причины", name, reason)
+			return true
+		}
+		pass.Reportf(arg.Pos(), "SQL для %s %s: используйте параметры ($1, ?) или //sqlvet:ignore с причиной", name, reason)
+		return true
+	})
+}
+
+// sqlArgIndex — номер аргумента с текстом SQL или -1, если вызов не проверяется.
+// Аргумент определяется по имени параметра: query (database/sql, sqlx) или sql (pgx, GORM).
+func sqlArgIndex(pass *analysis.Pass, call *ast.CallExpr) (int, string) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok {
+		return -1, ""
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil || !hasMethodPrefix(fn.Name()) {
+		return -1, ""
+	}
+
+	recv := sig.Recv().Type()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return -1, ""
+	}
+	typeName := named.Obj().Name()
+	if !targets[named.Obj().Pkg().Path()][typeName] {
+		return -1, ""
+	}
+
+	params := sig.Params()
+	for i := range params.Len() {
+		p := params.At(i)
+		if (p.Name() == "query" || p.Name() == "sql") && types.Identical(p.Type(), types.Typ[types.String]) {
+			return i, named.Obj().Pkg().Name() + "." + typeName + "." + fn.Name()
+		}
+	}
+	return -1, ""
+}
+
+func hasMethodPrefix(name string) bool {
+	for _, p := range methodPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsafeReason — почему выражение опасно, или "" если всё в порядке
+func unsafeReason(pass *analysis.Pass, e ast.Expr, assigns map[*types.Var][]ast.Expr) string {
+	if isConstant(pass, e) {
+		return ""
+	}
+
+	switch x := ast.Unparen(e).(type) {
+	case *ast.BinaryExpr:
+		if x.Op == token.ADD {
+			return "собран конкатенацией строк"
+		}
+	case *ast.CallExpr:
+		if isSprintf(pass, x) {
+			return "собран через fmt.Sprintf"
+		}
+	case *ast.Ident:
+		// Один шаг назад: q := "..." + name; db.Query(q)
+		v, ok := pass.TypesInfo.Uses[x].(*types.Var)
+		if !ok {
+			return ""
+		}
+		for _, rhs := range assigns[v] {
+			if r := unsafeReason(pass, rhs, nil); r != "" {
+				return "в переменной " + x.Name + " " + r
+			}
+		}
+	}
+	return ""
+}
+
+func isConstant(pass *analysis.Pass, e ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[e]
+	return ok && tv.Value != nil
+}
+
+func isSprintf(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" {
+		return false
+	}
+	switch fn.Name() {
+	case "Sprintf", "Sprint", "Sprintln":
+		return true
+	}
+	return false
+}
+
+// collectAssigns — выражения, присвоенные локальным переменным (:=, =, +=, var x = ...).
+// Для += сохраняем саму операцию как конкатенацию.
+func collectAssigns(pass *analysis.Pass, body *ast.BlockStmt) map[*types.Var][]ast.Expr {
+	assigns := make(map[*types.Var][]ast.Expr)
+	add := func(id *ast.Ident, rhs ast.Expr) {
+		obj := pass.TypesInfo.ObjectOf(id)
+		if v, ok := obj.(*types.Var); ok {
+			assigns[v] = append(assigns[v], rhs)
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			if len(s.Lhs) != len(s.Rhs) {
+				return true
+			}
+			for i, lhs := range s.Lhs {
+				id, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if s.Tok == token.ADD_ASSIGN {
+					add(id, &ast.BinaryExpr{X: lhs, Op: token.ADD, Y: s.Rhs[i], OpPos: s.TokPos})
+					continue
+				}
+				add(id, s.Rhs[i])
+			}
+		case *ast.ValueSpec:
+			if len(s.Names) != len(s.Values) {
+				return true
+			}
+			for i, id := range s.Names {
+				add(id, s.Values[i])
+			}
+		}
+		return true
+	})
+	return assigns
+}
+
+type lineKey struct {
+	file string
+	line int
+}
+
+// ignoredLines — строки с комментарием //sqlvet:ignore и причина из него ("" — причины нет)
+func ignoredLines(pass *analysis.Pass) map[lineKey]string {
+	lines := make(map[lineKey]string)
+	for _, f := range pass.Files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				rest, ok := strings.CutPrefix(c.Text, ignoreDirective)
+				// //sqlvet:ignored и подобные — не директива
+				if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+					continue
+				}
+				pos := pass.Fset.Position(c.Slash)
+				lines[lineKey{pos.Filename, pos.Line}] = strings.TrimSpace(rest)
+			}
+		}
+	}
+	return lines
+}
+
+// ignoreReason — причина из директивы строкой выше вызова или на любой из его строк; ok — директива есть
+func ignoreReason(pass *analysis.Pass, call *ast.CallExpr, ignored map[lineKey]string) (reason string, ok bool) {
+	start := pass.Fset.Position(call.Pos())
+	end := pass.Fset.Position(call.End())
+	for line := start.Line - 1; line <= end.Line; line++ {
+		if reason, ok := ignored[lineKey{start.Filename, line}]; ok {
+			return reason, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,14 @@
+package sqlvet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/akozadaev/go_db_20/20_sqlvet/sqlvet"
+)
+
+// Ожидаемые находки — комментарии // want в testdata/src/a
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), sqlvet.Analyzer, "a")
+}
@@ -0,0 +1,82 @@
+package a
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const usersColumns = "id, name"
+
+func concatenation(ctx context.Context, db *sql.DB, name string) {
+	db.Query("SELECT id FROM users WHERE name = '" + name + "'")            // want `SQL для sql.DB.Query собран конкатенацией строк`
+	db.QueryRowContext(ctx, "SELECT id FROM users WHERE name = '"+name+"'") // want `SQL для sql.DB.QueryRowContext собран конкатенацией строк`
+	db.ExecContext(ctx, ("DELETE FROM users WHERE name = '" + name + "'"))  // want `собран конкатенацией строк`
+	db.Prepare("SELECT " + name + " FROM users")                            // want `SQL для sql.DB.Prepare собран конкатенацией строк`
+	db.Exec("UPDATE users SET name = $1 WHERE id = "+fmt.Sprint(1), name)   // want `собран конкатенацией строк`
+	func() { db.Exec("DELETE FROM " + name) }()                             // want `SQL для sql.DB.Exec собран конкатенацией строк`
+}
+
+func sprintf(tx *sql.Tx, table string, id int) {
+	tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = %d", table, id)) // want `SQL для sql.Tx.Exec собран через fmt.Sprintf`
+	tx.Query(fmt.Sprint("SELECT * FROM ", table))                   // want `собран через fmt.Sprintf`
+}
+
+func variables(ctx context.Context, conn *sql.Conn, table string) {
+	q := "SELECT count(*) FROM " + table
+	conn.QueryRowContext(ctx, q) // want `SQL для sql.Conn.QueryRowContext в переменной q собран конкатенацией строк`
+
+	s := "SELECT count(*) FROM "
+	s += table
+	conn.QueryContext(ctx, s) // want `в переменной s собран конкатенацией строк`
+
+	var f = fmt.Sprintf("SELECT * FROM %s", table)
+	conn.ExecContext(ctx, f) // want `в переменной f собран через fmt.Sprintf`
+}
+
+func pgxConn(ctx context.Context, c *pgx.Conn, name string) {
+	c.Exec(ctx, "DELETE FROM users WHERE name = '"+name+"'") // want `SQL для pgx.Conn.Exec собран конкатенацией строк`
+	c.Exec(ctx, "DELETE FROM users WHERE name = $1", name)
+}
+
+func safe(ctx context.Context, db *sql.DB, name string) {
+	db.Query("SELECT id FROM users WHERE name = ?", name)
+	db.Query("SELECT " + usersColumns + " FROM users ORDER BY id")
+	db.QueryContext(ctx, "SELECT "+usersColumns+" FROM users WHERE name = $1", name)
+
+	const q = "SELECT " + usersColumns + " FROM users"
+	db.Query(q)
+
+	// Переменная без конкатенации: значение не собрано из строк
+	p := "SELECT id FROM users"
+	db.Query(p)
+
+	// Не запрос к базе: strings.NewReader и похожие вызовы не проверяются
+	strings.NewReader("SELECT " + name)
+}
+
+func ignored(db *sql.DB, table string) {
+	//sqlvet:ignore имя таблицы проверено по белому списку
+	db.Exec("DELETE FROM " + table)
+
+	db.Exec("DELETE FROM " + table) //sqlvet:ignore имя таблицы проверено по белому списку
+
+	//sqlvet:ignore многострочный вызов
+	db.Exec(
+		"DELETE FROM " + table,
+	)
+
+	//sqlvet:ignore
+	db.Exec("DELETE FROM " + table) // want `SQL для sql.DB.Exec собран конкатенацией строк: у //sqlvet:ignore нет причины`
+
+	//sqlvet:ignored опечатка в директиве
+	db.Exec("DELETE FROM " + table) // want `или //sqlvet:ignore с причиной`
+
+	// Директива действует только на следующую строку
+	//sqlvet:ignore слишком далеко
+
+	db.Exec("DELETE FROM " + table) // want `собран конкатенацией строк`
+}
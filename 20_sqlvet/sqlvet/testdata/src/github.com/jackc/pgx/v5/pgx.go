@@ -0,0 +1,12 @@
+// Package pgx — заглушка с сигнатурой Exec как у github.com/jackc/pgx/v5
+package pgx
+
+import "context"
+
+type Conn struct{}
+
+type CommandTag struct{}
+
+func (c *Conn) Exec(ctx context.Context, sql string, arguments ...any) (CommandTag, error) {
+	return CommandTag{}, nil
+}
@@ -0,0 +1,111 @@
+// Package ident — безопасная подстановка имён таблиц и колонок в SQL.
+//
+// Параметры ($1, ?) привязывают только значения, имена в запрос приходится вписывать текстом.
+// Set хранит известные имена (из живой схемы или белого списка) и отдаёт их уже в кавычках;
+// всё, чего в наборе нет, отклоняется с ErrUnknown, поэтому пользовательский ввод
+// попадает в SQL только как одно из заранее известных имён.
+package ident
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+var ErrUnknown = errors.New("неизвестный идентификатор")
+
+// Quote заключает имя в двойные кавычки по правилам диалекта.
+// Имя в кавычках чувствительно к регистру: "Users" и users в PostgreSQL — разные таблицы,
+// поэтому квотировать стоит только имена, взятые из схемы (см. Set).
+func Quote(d Dialect, name string) string {
+	if d == Postgres {
+		return pgx.Identifier{name}.Sanitize()
+	}
+	// SQLite понимает те же кавычки; нулевой байт обрезал бы имя, убираем его, как и pgx
+	name = strings.ReplaceAll(name, "\x00", "")
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Set — известные таблицы и их колонки. Заполняется до использования и дальше только читается,
+// поэтому безопасен для конкурентного чтения.
+type Set struct {
+	dialect Dialect
+	tables  map[string]map[string]bool
+}
+
+// NewSet создаёт пустой набор — белый список, который заполняется через Allow
+func NewSet(d Dialect) *Set {
+	return &Set{dialect: d, tables: make(map[string]map[string]bool)}
+}
+
+// Allow добавляет таблицу и её колонки; повторный вызов для той же таблицы дополняет список
+func (s *Set) Allow(table string, columns ...string) *Set {
+	cols, ok := s.tables[table]
+	if !ok {
+		cols = make(map[string]bool, len(columns))
+		s.tables[table] = cols
+	}
+	for _, c := range columns {
+		cols[c] = true
+	}
+	return s
+}
+
+func (s *Set) Dialect() Dialect {
+	return s.dialect
+}
+
+// Tables — известные таблицы по алфавиту
+func (s *Set) Tables() []string {
+	return slices.Sorted(maps.Keys(s.tables))
+}
+
+// Columns — известные колонки таблицы по алфавиту
+func (s *Set) Columns(table string) []string {
+	return slices.Sorted(maps.Keys(s.tables[table]))
+}
+
+// Table возвращает имя таблицы в кавычках или ErrUnknown
+func (s *Set) Table(name string) (string, error) {
+	if _, ok := s.tables[name]; !ok {
+		return "", fmt.Errorf("%w: таблица %q (допустимо: %s)", ErrUnknown, name, strings.Join(s.Tables(), ", "))
+	}
+	return Quote(s.dialect, name), nil
+}
+
+// Column возвращает имя колонки таблицы в кавычках или ErrUnknown
+func (s *Set) Column(table, name string) (string, error) {
+	cols, ok := s.tables[table]
+	if !ok {
+		return "", fmt.Errorf("%w: таблица %q", ErrUnknown, table)
+	}
+	if !cols[name] {
+		return "", fmt.Errorf("%w: колонка %q таблицы %s (допустимо: %s)",
+			ErrUnknown, name, table, strings.Join(s.Columns(table), ", "))
+	}
+	return Quote(s.dialect, name), nil
+}
+
+// ColumnList проверяет все имена и возвращает их в кавычках через запятую: "a", "b"
+func (s *Set) ColumnList(table string, names ...string) (string, error) {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		q, err := s.Column(table, n)
+		if err != nil {
+			return "", err
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, ", "), nil
+}
@@ -0,0 +1,41 @@
+package ident
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Load читает таблицы и представления текущей схемы вместе с колонками.
+// Набор — снимок: таблицы, созданные позже, не попадут в него до следующего Load.
+func Load(ctx context.Context, db *sql.DB, d Dialect) (*Set, error) {
+	query := `
+		SELECT table_name::text, column_name::text
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()`
+	if d == SQLite {
+		query = `
+			SELECT m.name, p.name
+			FROM sqlite_master m, pragma_table_info(m.name) p
+			WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'`
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+	defer rows.Close()
+
+	s := NewSet(d)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("ошибка чтения схемы: %w", err)
+		}
+		s.Allow(table, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+func main() {
+	ctx := context.Background()
+
+	path := filepath.Join(os.TempDir(), "ident_example.db")
+	defer os.Remove(path)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer db.Close()
+
+	if err := seed(ctx, db); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// 1. Имена из живой схемы: таблица и колонка сортировки приходят "от пользователя"
+	names, err := ident.Load(ctx, db, ident.SQLite)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println("Таблицы в схеме:", strings.Join(names.Tables(), ", "))
+
+	requests := []struct{ table, sort string }{
+		{"users", "age"},
+		{"users", "name"},
+		{"users", "age; DROP TABLE users"},
+		{"users; --", "id"},
+	}
+	for _, r := range requests {
+		fmt.Printf("\n%s ORDER BY %s\n", r.table, r.sort)
+		if err := listSorted(ctx, db, names, r.table, r.sort); err != nil {
+			if errors.Is(err, ident.ErrUnknown) {
+				fmt.Println("⛔ отклонено:", err)
+				continue
+			}
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	// 2. Белый список: наружу открыта только часть колонок, даже если в таблице есть другие
+	public := ident.NewSet(ident.SQLite).Allow("users", "id", "name")
+	if _, err := public.Column("users", "email"); err != nil {
+		fmt.Println("\n⛔ белый список:", err)
+	}
+
+	// 3. Квотирование по диалектам: кавычки внутри имени удваиваются
+	fmt.Println()
+	for _, name := range []string{"users", `we"ird`, "Order"} {
+		fmt.Printf("%-8s PostgreSQL: %-10s SQLite: %s\n", name, ident.Quote(ident.Postgres, name), ident.Quote(ident.SQLite, name))
+	}
+}
+
+func seed(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		email TEXT,
+		age INTEGER
+	)`); err != nil {
+		return fmt.Errorf("ошибка создания таблицы: %w", err)
+	}
+	for _, u := range []struct {
+		name string
+		age  int
+	}{{"Иван", 30}, {"Анна", 25}, {"Пётр", 41}} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO users (name, email, age) VALUES (?, ?, ?)",
+			u.name, strings.ToLower(u.name)+"@example.com", u.age); err != nil {
+			return fmt.Errorf("ошибка вставки: %w", err)
+		}
+	}
+	return nil
+}
+
+// listSorted — пример динамического ORDER BY: имена проходят через Set, значения — через параметры
+func listSorted(ctx context.Context, db *sql.DB, names *ident.Set, table, sortColumn string) error {
+	t, err := names.Table(table)
+	if err != nil {
+		return err
+	}
+	col, err := names.Column(table, sortColumn)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT id, name, age FROM %s WHERE age >= ? ORDER BY %s", t, col)
+	rows, err := db.QueryContext(ctx, query, 18) //sqlvet:ignore имена проверены ident.Set
+	if err != nil {
+		return fmt.Errorf("ошибка запроса: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   int
+			name string
+			age  int
+		)
+		if err := rows.Scan(&id, &name, &age); err != nil {
+			return err
+		}
+		fmt.Printf("  %d %s %d\n", id, name, age)
+	}
+	return rows.Err()
+}
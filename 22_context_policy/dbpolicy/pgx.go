@@ -0,0 +1,143 @@
+package dbpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CancelOnServer настраивает реакцию pgx на отмену контекста.
+// По умолчанию pgx просто рвёт соединение, а сервер продолжает выполнять запрос,
+// пока не попробует отправить результат. С CancelRequest сервер прерывает запрос сразу
+// (ошибка 57014), а соединение остаётся живым и возвращается в пул.
+// Если сервер не ответил за deadlineDelay, соединение всё же закрывается.
+func CancelOnServer(cfg *pgconn.Config, deadlineDelay time.Duration) {
+	cfg.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{
+			Conn:          conn,
+			DeadlineDelay: deadlineDelay,
+		}
+	}
+}
+
+// Pool — pgxpool с дедлайнами политики для каждой операции
+type Pool struct {
+	pool   *pgxpool.Pool
+	policy Policy
+}
+
+// NewPool создаёт пул с отменой запросов на сервере
+func NewPool(ctx context.Context, dsn string, p Policy) (*Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора DSN: %w", err)
+	}
+	CancelOnServer(&cfg.ConnConfig.Config, time.Second)
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пул: %w", err)
+	}
+	return &Pool{pool: pool, policy: p}, nil
+}
+
+// Pool — исходный пул для кода, которому политика не нужна (миграции, COPY)
+func (p *Pool) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
+func (p *Pool) Ping(ctx context.Context) error {
+	ctx, cancel := p.policy.ReadContext(ctx)
+	defer cancel()
+	return p.pool.Ping(ctx)
+}
+
+func (p *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, cancel := p.policy.WriteContext(ctx)
+	defer cancel()
+	return p.pool.Exec(ctx, sql, args...)
+}
+
+// Query — дедлайн чтения действует, пока строки не прочитаны или не закрыты
+func (p *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := p.policy.ReadContext(ctx)
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &pgxRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := p.policy.ReadContext(ctx)
+	return &pgxRow{row: p.pool.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+// InTx выполняет fn в транзакции с дедлайном записи на всю транзакцию: Commit при nil, иначе Rollback.
+// Rollback выполняется и после отмены контекста — иначе соединение вернулось бы в пул с открытой транзакцией.
+func (p *Pool) InTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx, cancel := p.policy.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	if err := fn(ctx, tx); err != nil {
+		return errors.Join(err, rollback(ctx, tx))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка коммита: %w", err)
+	}
+	return nil
+}
+
+func rollback(ctx context.Context, tx pgx.Tx) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+	defer cancel()
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return fmt.Errorf("ошибка отката: %w", err)
+	}
+	return nil
+}
+
+// Close ждёт возврата соединений не дольше Policy.Shutdown
+func (p *Pool) Close() error {
+	return p.policy.Close(p.pool.Close)
+}
+
+// pgxRows снимает дедлайн, когда строки закончились или закрыты
+type pgxRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *pgxRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.cancel()
+	return false
+}
+
+func (r *pgxRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+type pgxRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *pgxRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
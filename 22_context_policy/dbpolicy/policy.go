@@ -0,0 +1,75 @@
+// Package dbpolicy — дедлайны по умолчанию для операций с базой и остановка по сигналу.
+//
+// Каждая операция получает контекст вызывающего кода и ограничивается дедлайном политики:
+// чтение — Read, запись и транзакция целиком — Write. Более ранний дедлайн вызывающего кода
+// сохраняется (context.WithTimeout его не продлевает), отмена по сигналу доходит до сервера.
+package dbpolicy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var ErrShutdownTimeout = errors.New("пул не закрылся за отведённое время")
+
+// Policy — дедлайны по умолчанию; ноль — без дедлайна, только отмена контекста
+type Policy struct {
+	Read     time.Duration // SELECT и другие запросы, возвращающие строки
+	Write    time.Duration // INSERT/UPDATE/DELETE, DDL и транзакции целиком
+	Shutdown time.Duration // сколько ждать закрытия пула после отмены
+}
+
+var Default = Policy{
+	Read:     2 * time.Second,
+	Write:    5 * time.Second,
+	Shutdown: 10 * time.Second,
+}
+
+// ReadContext — контекст для чтения; cancel обязателен, как у context.WithTimeout
+func (p Policy) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, p.Read)
+}
+
+// WriteContext — контекст для записи или транзакции
+func (p Policy) WriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, p.Write)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// SignalContext отменяется по SIGINT или SIGTERM: все запросы с этим контекстом прерываются.
+// stop возвращает сигналам поведение по умолчанию — повторный Ctrl+C завершит процесс сразу.
+func SignalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+// Close вызывает closeFn и ждёт его не дольше p.Shutdown.
+// pgxpool.Pool.Close и sql.DB.Close ждут, пока вернутся занятые соединения:
+// если запросы уже отменены, это быстро, а зависший запрос не должен держать процесс вечно.
+func (p Policy) Close(closeFn func()) error {
+	done := make(chan struct{})
+	go func() {
+		closeFn()
+		close(done)
+	}()
+
+	if p.Shutdown <= 0 {
+		<-done
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-time.After(p.Shutdown):
+		return ErrShutdownTimeout
+	}
+}
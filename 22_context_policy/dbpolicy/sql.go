@@ -0,0 +1,126 @@
+package dbpolicy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DB — *sql.DB с дедлайнами политики. Отмена доходит до сервера через драйвер:
+// pgx/stdlib (с CancelOnServer, см. OpenPgx), lib/pq — CancelRequest, go-sqlite3 — sqlite3_interrupt.
+type DB struct {
+	db     *sql.DB
+	policy Policy
+}
+
+func NewDB(db *sql.DB, p Policy) *DB {
+	return &DB{db: db, policy: p}
+}
+
+// OpenPgx открывает *sql.DB поверх pgx с отменой запросов на сервере
+func OpenPgx(dsn string, p Policy) (*DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора DSN: %w", err)
+	}
+	CancelOnServer(&cfg.Config, time.Second)
+	return NewDB(stdlib.OpenDB(*cfg), p), nil
+}
+
+// DB — исходный *sql.DB
+func (d *DB) DB() *sql.DB {
+	return d.db
+}
+
+func (d *DB) PingContext(ctx context.Context) error {
+	ctx, cancel := d.policy.ReadContext(ctx)
+	defer cancel()
+	return d.db.PingContext(ctx)
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := d.policy.WriteContext(ctx)
+	defer cancel()
+	return d.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext — дедлайн чтения действует, пока строки не прочитаны или не закрыты
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	ctx, cancel := d.policy.ReadContext(ctx)
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	ctx, cancel := d.policy.ReadContext(ctx)
+	return &Row{row: d.db.QueryRowContext(ctx, query, args...), cancel: cancel}
+}
+
+// InTx выполняет fn в транзакции с дедлайном записи на всю транзакцию: Commit при nil, иначе Rollback.
+// После отмены контекста database/sql откатывает транзакцию сам, ErrTxDone здесь не ошибка.
+func (d *DB) InTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := d.policy.WriteContext(ctx)
+	defer cancel()
+
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	if err := fn(ctx, tx); err != nil {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("ошибка отката: %w", rerr))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка коммита: %w", err)
+	}
+	return nil
+}
+
+// Close не даёт начать новые запросы и ждёт начатые не дольше Policy.Shutdown
+func (d *DB) Close() error {
+	var err error
+	if terr := d.policy.Close(func() { err = d.db.Close() }); terr != nil {
+		return terr
+	}
+	return err
+}
+
+// Rows — *sql.Rows, снимающие дедлайн после чтения или Close
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.cancel()
+	return false
+}
+
+func (r *Rows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+type Row struct {
+	row    *sql.Row
+	cancel context.CancelFunc
+}
+
+func (r *Row) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
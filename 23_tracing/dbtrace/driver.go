@@ -0,0 +1,408 @@
+package dbtrace
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDriver оборачивает драйвер database/sql: Exec, Query, Prepare, Commit и Rollback
+// становятся спанами. system — semconv.DBSystemNamePostgreSQL, semconv.DBSystemNameSQLite и т.п.
+// Необязательные интерфейсы драйвера (NamedValueChecker, SessionResetter, ColumnType...)
+// пробрасываются, поэтому обёртка не меняет поведение database/sql.
+func WrapDriver(d driver.Driver, system attribute.KeyValue, tp trace.TracerProvider) driver.Driver {
+	return &tracedDriver{d: d, system: system, tracer: newTracer(tp)}
+}
+
+// OpenDB открывает *sql.DB поверх обёрнутого драйвера — без sql.Register под новым именем
+func OpenDB(d driver.Driver, dsn string, system attribute.KeyValue, tp trace.TracerProvider) (*sql.DB, error) {
+	c, err := WrapDriver(d, system, tp).(*tracedDriver).OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(c), nil
+}
+
+type tracedDriver struct {
+	d      driver.Driver
+	system attribute.KeyValue
+	tracer trace.Tracer
+}
+
+func (d *tracedDriver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.d.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{conn: c, d: d}, nil
+}
+
+func (d *tracedDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	if dc, ok := d.d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &tracedConnector{c: c, d: d}, nil
+	}
+	return &tracedConnector{c: dsnConnector{dsn: dsn, d: d.d}, d: d}, nil
+}
+
+func (d *tracedDriver) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name, attrs := queryAttrs(d.system, query)
+	return d.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+type dsnConnector struct {
+	dsn string
+	d   driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.d }
+
+type tracedConnector struct {
+	c driver.Connector
+	d *tracedDriver
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{conn: conn, d: c.d}, nil
+}
+
+func (c *tracedConnector) Driver() driver.Driver { return c.d }
+
+type tracedConn struct {
+	conn driver.Conn
+	d    *tracedDriver
+}
+
+var (
+	_ driver.ConnPrepareContext = (*tracedConn)(nil)
+	_ driver.ConnBeginTx        = (*tracedConn)(nil)
+	_ driver.ExecerContext      = (*tracedConn)(nil)
+	_ driver.QueryerContext     = (*tracedConn)(nil)
+	_ driver.Pinger             = (*tracedConn)(nil)
+	_ driver.SessionResetter    = (*tracedConn)(nil)
+	_ driver.Validator          = (*tracedConn)(nil)
+	_ driver.NamedValueChecker  = (*tracedConn)(nil)
+)
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	_, span := c.d.tracer.Start(ctx, "PREPARE", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(c.d.system, semconv.DBOperationName("PREPARE"), semconv.DBQueryText(query)))
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{stmt: stmt, query: query, conn: c}, nil
+}
+
+func (c *tracedConn) Close() error { return c.conn.Close() }
+
+func (c *tracedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if bt, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = bt.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.conn.Begin() // драйвер без BeginTx
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Commit и Rollback не получают контекст — запоминаем контекст начала транзакции
+	return &tracedTx{tx: tx, ctx: ctx, d: c.d}, nil
+}
+
+// ExecContext — если драйвер не умеет Exec без подготовки, database/sql сделает Prepare + Exec,
+// и спан появится у подготовленного запроса
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ex, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.d.start(ctx, query)
+	res, err := ex.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		// Запрос выполнится через Prepare, и спан будет у него; этот не экспортируем
+		return nil, err
+	}
+	if err == nil {
+		setAffected(span, res)
+	}
+	endSpan(span, err)
+	return res, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.d.start(ctx, query)
+	rows, err := q.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{rows: rows, span: span}, nil
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedStmt struct {
+	stmt  driver.Stmt
+	query string
+	conn  *tracedConn
+}
+
+var (
+	_ driver.StmtExecContext   = (*tracedStmt)(nil)
+	_ driver.StmtQueryContext  = (*tracedStmt)(nil)
+	_ driver.NamedValueChecker = (*tracedStmt)(nil)
+)
+
+func (s *tracedStmt) Close() error  { return s.stmt.Close() }
+func (s *tracedStmt) NumInput() int { return s.stmt.NumInput() }
+
+func (s *tracedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *tracedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := s.conn.d.start(ctx, s.query)
+	var (
+		res driver.Result
+		err error
+	)
+	if ec, ok := s.stmt.(driver.StmtExecContext); ok {
+		res, err = ec.ExecContext(ctx, args)
+	} else {
+		res, err = s.stmt.Exec(values(args)) // драйвер без ExecContext
+	}
+	if err == nil {
+		setAffected(span, res)
+	}
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := s.conn.d.start(ctx, s.query)
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if qc, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(values(args)) // драйвер без QueryContext
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{rows: rows, span: span}, nil
+}
+
+// CheckNamedValue — database/sql спрашивает запрос, а не соединение, если запрос умеет проверять
+// значения; у pgx/stdlib проверка есть только у соединения, поэтому спрашиваем и его
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// tracedRows завершает спан запроса при Close — в нём видно, сколько строк прочитано
+type tracedRows struct {
+	rows driver.Rows
+	span trace.Span
+	n    int
+	err  error
+}
+
+var (
+	_ driver.RowsColumnTypeScanType         = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeLength           = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*tracedRows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*tracedRows)(nil)
+	_ driver.RowsNextResultSet              = (*tracedRows)(nil)
+)
+
+func (r *tracedRows) Columns() []string { return r.rows.Columns() }
+
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case !errors.Is(err, io.EOF):
+		r.err = err
+	}
+	return err
+}
+
+func (r *tracedRows) Close() error {
+	err := r.rows.Close()
+	r.span.SetAttributes(semconv.DBResponseReturnedRows(r.n))
+	endSpan(r.span, errors.Join(r.err, err))
+	return err
+}
+
+func (r *tracedRows) ColumnTypeScanType(i int) reflect.Type {
+	if ct, ok := r.rows.(driver.RowsColumnTypeScanType); ok {
+		return ct.ColumnTypeScanType(i)
+	}
+	return reflect.TypeFor[any]()
+}
+
+func (r *tracedRows) ColumnTypeDatabaseTypeName(i int) string {
+	if ct, ok := r.rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return ct.ColumnTypeDatabaseTypeName(i)
+	}
+	return ""
+}
+
+func (r *tracedRows) ColumnTypeLength(i int) (int64, bool) {
+	if ct, ok := r.rows.(driver.RowsColumnTypeLength); ok {
+		return ct.ColumnTypeLength(i)
+	}
+	return 0, false
+}
+
+func (r *tracedRows) ColumnTypeNullable(i int) (bool, bool) {
+	if ct, ok := r.rows.(driver.RowsColumnTypeNullable); ok {
+		return ct.ColumnTypeNullable(i)
+	}
+	return false, false
+}
+
+func (r *tracedRows) ColumnTypePrecisionScale(i int) (int64, int64, bool) {
+	if ct, ok := r.rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ct.ColumnTypePrecisionScale(i)
+	}
+	return 0, 0, false
+}
+
+func (r *tracedRows) HasNextResultSet() bool {
+	if nrs, ok := r.rows.(driver.RowsNextResultSet); ok {
+		return nrs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *tracedRows) NextResultSet() error {
+	if nrs, ok := r.rows.(driver.RowsNextResultSet); ok {
+		return nrs.NextResultSet()
+	}
+	return io.EOF
+}
+
+type tracedTx struct {
+	tx  driver.Tx
+	ctx context.Context
+	d   *tracedDriver
+}
+
+func (t *tracedTx) Commit() error {
+	_, span := t.d.start(t.ctx, "COMMIT")
+	err := t.tx.Commit()
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	_, span := t.d.start(t.ctx, "ROLLBACK")
+	err := t.tx.Rollback()
+	endSpan(span, err)
+	return err
+}
+
+func setAffected(span trace.Span, res driver.Result) {
+	if n, err := res.RowsAffected(); err == nil {
+		span.SetAttributes(RowsAffectedKey.Int64(n))
+	}
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nv
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	v := make([]driver.Value, len(args))
+	for i, a := range args {
+		v[i] = a.Value
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package dbtrace_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/akozadaev/go_db_20/23_tracing/dbtrace"
+)
+
+func TestWrapDriverSQLite(t *testing.T) {
+	tp, exporter := newProvider(t)
+	db, err := dbtrace.OpenDB(&sqlite3.SQLiteDriver{}, filepath.Join(t.TempDir(), "trace.db"), semconv.DBSystemNameSQLite, tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Корневой спан — как у обработчика HTTP-запроса; спаны запросов станут его детьми
+	ctx, root := tp.Tracer("test").Start(context.Background(), "test")
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, age INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []struct {
+		name string
+		age  int
+	}{{"Alexey", 45}, {"Maria", 31}, {"Ivan", 52}} {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO users (name, age) VALUES (?, ?)", u.name, u.age); err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE users SET age = age + 1 WHERE age > 40"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, age FROM users WHERE age > ? ORDER BY id", 18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := db.PrepareContext(ctx, "DELETE FROM users WHERE name = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.ExecContext(ctx, "Maria"); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM missing_table WHERE id = 1"); err == nil {
+		t.Fatal("ожидалась ошибка для несуществующей таблицы")
+	}
+	root.End()
+
+	spans := exporter.GetSpans()
+	checkSpans(t, spans, root.SpanContext().TraceID(), []expectation{
+		{name: "CREATE users"},
+		{name: "INSERT users", attr: dbtrace.RowsAffectedKey, value: "1"},
+		{name: "COMMIT"},
+		{name: "UPDATE users", attr: dbtrace.RowsAffectedKey, value: "2"},
+		{name: "SELECT users", attr: semconv.DBResponseReturnedRowsKey, value: "3"},
+		{name: "SELECT users", attr: dbtrace.NormalizedQueryKey, value: "SELECT name, age FROM users WHERE age > ? ORDER BY id"},
+		{name: "PREPARE", attr: semconv.DBQueryTextKey, value: "DELETE FROM users WHERE name = ?"},
+		{name: "DELETE users", attr: dbtrace.RowsAffectedKey, value: "1"},
+		{name: "DELETE missing_table", failed: true},
+	})
+
+	// Значения параметров в спаны не попадают
+	for _, s := range spans {
+		for _, kv := range s.Attributes {
+			if v := kv.Value.Emit(); strings.Contains(v, "Alexey") || strings.Contains(v, "Maria") {
+				t.Errorf("значение параметра в спане %s: %s=%s", s.Name, kv.Key, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package dbtrace
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	_ pgx.QueryTracer       = (*PgxTracer)(nil)
+	_ pgx.BatchTracer       = (*PgxTracer)(nil)
+	_ pgx.CopyFromTracer    = (*PgxTracer)(nil)
+	_ pgxpool.AcquireTracer = (*PgxTracer)(nil)
+	_ pgxpool.ReleaseTracer = (*PgxTracer)(nil)
+)
+
+// PgxTracer — трейсер для pgx.ConnConfig.Tracer. pgxpool сам находит в нём AcquireTracer:
+// ожидание соединения становится отдельным спаном, а его длительность — атрибутом
+// первого запроса на полученном соединении.
+type PgxTracer struct {
+	tracer trace.Tracer
+
+	mu    sync.Mutex
+	waits map[*pgx.Conn]time.Duration
+}
+
+// NewPgxTracer создаёт трейсер; tp == nil — глобальный otel.GetTracerProvider()
+func NewPgxTracer(tp trace.TracerProvider) *PgxTracer {
+	return &PgxTracer{tracer: newTracer(tp), waits: make(map[*pgx.Conn]time.Duration)}
+}
+
+func (t *PgxTracer) start(ctx context.Context, name string, opts ...trace.SpanStartOption) context.Context {
+	opts = append(opts, trace.WithSpanKind(trace.SpanKindClient))
+	ctx, _ = t.tracer.Start(ctx, name, opts...)
+	return ctx
+}
+
+func (t *PgxTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name, attrs := queryAttrs(semconv.DBSystemNamePostgreSQL, data.SQL)
+	if wait, ok := t.takeWait(conn); ok {
+		attrs = append(attrs, waitAttr(wait))
+	}
+	return t.start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func (t *PgxTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		setRows(span, data.CommandTag)
+	}
+	endSpan(span, data.Err)
+}
+
+// TraceBatchStart — один спан на весь пакет, запросы пакета — события спана
+func (t *PgxTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	attrs := []trace.SpanStartOption{trace.WithAttributes(
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName("BATCH"),
+		semconv.DBOperationBatchSize(data.Batch.Len()),
+	)}
+	if wait, ok := t.takeWait(conn); ok {
+		attrs = append(attrs, trace.WithAttributes(waitAttr(wait)))
+	}
+	return t.start(ctx, "BATCH", attrs...)
+}
+
+func (t *PgxTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span := trace.SpanFromContext(ctx)
+	_, attrs := queryAttrs(semconv.DBSystemNamePostgreSQL, data.SQL)
+	if data.Err != nil {
+		span.RecordError(data.Err, trace.WithAttributes(attrs...))
+		return
+	}
+	if data.CommandTag.Select() {
+		attrs = append(attrs, semconv.DBResponseReturnedRows(int(data.CommandTag.RowsAffected())))
+	} else {
+		attrs = append(attrs, RowsAffectedKey.Int64(data.CommandTag.RowsAffected()))
+	}
+	span.AddEvent("db.batch.query", trace.WithAttributes(attrs...))
+}
+
+func (t *PgxTracer) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+func (t *PgxTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := strings.Join(data.TableName, ".")
+	cols := make([]string, len(data.ColumnNames))
+	for i, c := range data.ColumnNames {
+		cols[i] = pgx.Identifier{c}.Sanitize()
+	}
+	// Такой текст pgx отправляет на сервер сам
+	query := "COPY " + data.TableName.Sanitize() + " ( " + strings.Join(cols, ", ") + " ) FROM STDIN BINARY"
+
+	attrs := []trace.SpanStartOption{trace.WithAttributes(
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBQueryText(query),
+		semconv.DBOperationName("COPY"),
+		semconv.DBCollectionName(table),
+		semconv.DBQuerySummary("COPY "+table),
+	)}
+	if wait, ok := t.takeWait(conn); ok {
+		attrs = append(attrs, trace.WithAttributes(waitAttr(wait)))
+	}
+	return t.start(ctx, "COPY "+table, attrs...)
+}
+
+func (t *PgxTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		span.SetAttributes(RowsAffectedKey.Int64(data.CommandTag.RowsAffected()))
+	}
+	endSpan(span, data.Err)
+}
+
+type acquireStartKey struct{}
+
+func (t *PgxTracer) TraceAcquireStart(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireStartData) context.Context {
+	ctx = t.start(ctx, "pgxpool.acquire", trace.WithAttributes(semconv.DBSystemNamePostgreSQL))
+	return context.WithValue(ctx, acquireStartKey{}, time.Now())
+}
+
+func (t *PgxTracer) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	span := trace.SpanFromContext(ctx)
+	if started, ok := ctx.Value(acquireStartKey{}).(time.Time); ok {
+		wait := time.Since(started)
+		span.SetAttributes(waitAttr(wait))
+		if data.Conn != nil {
+			t.mu.Lock()
+			t.waits[data.Conn] = wait
+			t.mu.Unlock()
+		}
+	}
+	endSpan(span, data.Err)
+}
+
+// TraceRelease забывает ожидание, если на соединении так и не выполнили запрос (например, Ping)
+func (t *PgxTracer) TraceRelease(pool *pgxpool.Pool, data pgxpool.TraceReleaseData) {
+	t.mu.Lock()
+	delete(t.waits, data.Conn)
+	t.mu.Unlock()
+}
+
+// takeWait — ожидание в пуле перед первым запросом на соединении
+func (t *PgxTracer) takeWait(conn *pgx.Conn) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	wait, ok := t.waits[conn]
+	if ok {
+		delete(t.waits, conn)
+	}
+	return wait, ok
+}
+
+// setRows — для SELECT число строк в ответе, для остальных команд — затронутые строки
+func setRows(span trace.Span, tag pgconn.CommandTag) {
+	if tag.String() == "" {
+		return
+	}
+	if tag.Select() {
+		span.SetAttributes(semconv.DBResponseReturnedRows(int(tag.RowsAffected())))
+		return
+	}
+	span.SetAttributes(RowsAffectedKey.Int64(tag.RowsAffected()))
+}
@@ -0,0 +1,88 @@
+package dbtrace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/akozadaev/go_db_20/23_tracing/dbtrace"
+)
+
+// Хуки вызываются напрямую в том порядке, в каком их вызывают pgx и pgxpool, — PostgreSQL не нужен
+func TestPgxTracer(t *testing.T) {
+	tp, exporter := newProvider(t)
+	tracer := dbtrace.NewPgxTracer(tp)
+	ctx, root := tp.Tracer("test").Start(context.Background(), "test")
+	conn := &pgx.Conn{}
+
+	actx := tracer.TraceAcquireStart(ctx, nil, pgxpool.TraceAcquireStartData{})
+	tracer.TraceAcquireEnd(actx, nil, pgxpool.TraceAcquireEndData{Conn: conn})
+
+	// Первый запрос на соединении получает время ожидания в пуле
+	qctx := tracer.TraceQueryStart(ctx, conn, pgx.TraceQueryStartData{SQL: "CREATE TEMP TABLE trace_events (id int, payload text)"})
+	tracer.TraceQueryEnd(qctx, conn, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("CREATE TABLE")})
+
+	cctx := tracer.TraceCopyFromStart(ctx, conn, pgx.TraceCopyFromStartData{
+		TableName:   pgx.Identifier{"trace_events"},
+		ColumnNames: []string{"id", "payload"},
+	})
+	tracer.TraceCopyFromEnd(cctx, conn, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 3")})
+
+	batch := &pgx.Batch{}
+	batch.Queue("UPDATE trace_events SET payload = upper(payload) WHERE id <= $1", 2)
+	batch.Queue("SELECT count(*) FROM trace_events")
+	bctx := tracer.TraceBatchStart(ctx, conn, pgx.TraceBatchStartData{Batch: batch})
+	tracer.TraceBatchQuery(bctx, conn, pgx.TraceBatchQueryData{SQL: batch.QueuedQueries[0].SQL, CommandTag: pgconn.NewCommandTag("UPDATE 2")})
+	tracer.TraceBatchQuery(bctx, conn, pgx.TraceBatchQueryData{SQL: batch.QueuedQueries[1].SQL, CommandTag: pgconn.NewCommandTag("SELECT 1")})
+	tracer.TraceBatchEnd(bctx, conn, pgx.TraceBatchEndData{})
+
+	qctx = tracer.TraceQueryStart(ctx, conn, pgx.TraceQueryStartData{SQL: "SELECT payload FROM trace_events WHERE id = $1"})
+	tracer.TraceQueryEnd(qctx, conn, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	missing := &pgconn.PgError{Code: "42P01", Message: `relation "missing_table" does not exist`}
+	qctx = tracer.TraceQueryStart(ctx, conn, pgx.TraceQueryStartData{SQL: "SELECT * FROM missing_table"})
+	tracer.TraceQueryEnd(qctx, conn, pgx.TraceQueryEndData{Err: missing})
+
+	// Соединение вернули в пул без запросов: ожидание не должно достаться следующему
+	other := &pgx.Conn{}
+	actx = tracer.TraceAcquireStart(ctx, nil, pgxpool.TraceAcquireStartData{})
+	tracer.TraceAcquireEnd(actx, nil, pgxpool.TraceAcquireEndData{Conn: other})
+	tracer.TraceRelease(nil, pgxpool.TraceReleaseData{Conn: other})
+	qctx = tracer.TraceQueryStart(ctx, other, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(qctx, other, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	actx = tracer.TraceAcquireStart(ctx, nil, pgxpool.TraceAcquireStartData{})
+	tracer.TraceAcquireEnd(actx, nil, pgxpool.TraceAcquireEndData{Err: errors.New("пул закрыт")})
+	root.End()
+
+	spans := exporter.GetSpans()
+	checkSpans(t, spans, root.SpanContext().TraceID(), []expectation{
+		{name: "pgxpool.acquire", attr: dbtrace.AcquireWaitKey},
+		{name: "pgxpool.acquire", failed: true},
+		{name: "CREATE trace_events", attr: dbtrace.AcquireWaitKey},
+		{name: "COPY trace_events", attr: dbtrace.RowsAffectedKey, value: "3"},
+		{name: "COPY trace_events", attr: semconv.DBQueryTextKey, value: `COPY "trace_events" ( "id", "payload" ) FROM STDIN BINARY`},
+		{name: "BATCH", attr: semconv.DBOperationBatchSizeKey, value: "2"},
+		{name: "SELECT trace_events", attr: semconv.DBResponseReturnedRowsKey, value: "1"},
+		{name: "SELECT trace_events", attr: dbtrace.NormalizedQueryKey, value: "SELECT payload FROM trace_events WHERE id = $1"},
+		{name: "SELECT missing_table", failed: true, attr: semconv.DBResponseStatusCodeKey, value: "42P01"},
+	})
+
+	for _, s := range spans {
+		switch s.Name {
+		case "BATCH":
+			if len(s.Events) != 2 {
+				t.Errorf("BATCH: событий %d, ожидалось 2", len(s.Events))
+			}
+		case "SELECT trace_events", "COPY trace_events", "SELECT":
+			if matches(s, expectation{name: s.Name, attr: dbtrace.AcquireWaitKey}) {
+				t.Errorf("%s: ожидание в пуле досталось не первому запросу на соединении", s.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package dbtrace — спаны OpenTelemetry для запросов к базе.
+//
+// PgxTracer подключается к pgx через ConnConfig.Tracer и видит запросы, пакеты (SendBatch),
+// COPY FROM и ожидание соединения в pgxpool. WrapDriver оборачивает драйвер database/sql.
+// У каждого спана: текст запроса (db.query.text), нормализованный текст без значений
+// (db.query.normalized), число строк и ошибка с кодом SQLSTATE, если драйвер его отдаёт.
+// Значения параметров в спаны не попадают.
+package dbtrace
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/akozadaev/go_db_20/23_tracing/dbtrace"
+
+// Атрибуты, которых нет в семантических соглашениях OpenTelemetry
+const (
+	NormalizedQueryKey = attribute.Key("db.query.normalized")
+	RowsAffectedKey    = attribute.Key("db.response.rows_affected")
+	AcquireWaitKey     = attribute.Key("db.client.connection.wait_time_ms")
+)
+
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+// queryAttrs — атрибуты запроса; имя спана строится из тех же операции и таблицы
+func queryAttrs(system attribute.KeyValue, sql string) (string, []attribute.KeyValue) {
+	op, coll := summarize(sql)
+	name := spanName(op, coll)
+	attrs := []attribute.KeyValue{
+		system,
+		semconv.DBQueryText(sql),
+		NormalizedQueryKey.String(Normalize(sql)),
+		semconv.DBQuerySummary(name),
+	}
+	if op != "" {
+		attrs = append(attrs, semconv.DBOperationName(op))
+	}
+	if coll != "" {
+		attrs = append(attrs, semconv.DBCollectionName(coll))
+	}
+	return name, attrs
+}
+
+func waitAttr(d time.Duration) attribute.KeyValue {
+	return AcquireWaitKey.Float64(float64(d) / float64(time.Millisecond))
+}
+
+// sqlStater — ошибки с кодом SQLSTATE: *pgconn.PgError, *pq.Error
+type sqlStater interface {
+	SQLState() string
+}
+
+// endSpan отмечает ошибку и завершает спан
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		recordError(span, err)
+	}
+	span.End()
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	var st sqlStater
+	if errors.As(err, &st) {
+		span.SetAttributes(semconv.DBResponseStatusCode(st.SQLState()))
+	}
+}
@@ -0,0 +1,64 @@
+package dbtrace_test
+
+import (
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// expectation — какой спан должен быть: имя, атрибут (и его значение, если задано), ошибка
+type expectation struct {
+	name   string
+	attr   attribute.Key
+	value  string
+	failed bool
+}
+
+// newProvider — спаны складываются в память, коллектор не нужен
+func newProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(t.Context()) })
+	return tp, exporter
+}
+
+// checkSpans сверяет спаны с ожиданиями; все спаны должны быть в трассе traceID
+func checkSpans(t *testing.T, spans tracetest.SpanStubs, traceID trace.TraceID, want []expectation) {
+	t.Helper()
+	for _, w := range want {
+		if !slices.ContainsFunc(spans, func(s tracetest.SpanStub) bool { return matches(s, w) }) {
+			t.Errorf("нет спана %s %s=%q (ошибка: %v)", w.name, w.attr, w.value, w.failed)
+		}
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != traceID {
+			t.Errorf("спан %s вне трассы теста", s.Name)
+		}
+	}
+	if t.Failed() {
+		for _, s := range spans {
+			t.Logf("  %s %v %v", s.Name, s.Status, s.Attributes)
+		}
+	}
+}
+
+func matches(s tracetest.SpanStub, w expectation) bool {
+	if s.Name != w.name || (s.Status.Code == codes.Error) != w.failed {
+		return false
+	}
+	if w.attr == "" {
+		return true
+	}
+	for _, kv := range s.Attributes {
+		if kv.Key == w.attr {
+			return w.value == "" || kv.Value.Emit() == w.value
+		}
+	}
+	return false
+}
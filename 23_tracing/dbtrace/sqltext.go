@@ -0,0 +1,56 @@
+package dbtrace
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	lineComment  = regexp.MustCompile(`--[^\n]*`)
+	blockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	stringLit    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// Число не должно быть частью имени или параметра: users2 и $1 не трогаем
+	numberLit  = regexp.MustCompile(`(^|[^\w$."])\d+(?:\.\d+)?`)
+	valueList  = regexp.MustCompile(`\((?:\s*(?:\?|\$\d+)\s*,)+\s*(?:\?|\$\d+)\s*\)`)
+	whitespace = regexp.MustCompile(`\s+`)
+
+	collectionRe = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|JOIN|TABLE)\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?("?[\w.]+"?)`)
+)
+
+// Normalize приводит запрос к виду для группировки: без комментариев и литералов,
+// списки IN (?, ?, ?) и VALUES ($1, $2) схлопнуты в (...), пробелы — одиночные.
+// Запросы, отличающиеся только значениями, нормализуются одинаково.
+func Normalize(sql string) string {
+	s := blockComment.ReplaceAllString(sql, " ")
+	s = lineComment.ReplaceAllString(s, " ")
+	s = stringLit.ReplaceAllString(s, "?")
+	s = numberLit.ReplaceAllString(s, "${1}?")
+	s = valueList.ReplaceAllString(s, "(...)")
+	s = whitespace.ReplaceAllString(s, " ")
+	return strings.TrimRight(strings.TrimSpace(s), ";")
+}
+
+// summarize — операция и первая таблица запроса: "SELECT", "accounts".
+// Из них составляется имя спана — короткое и без значений, чтобы спаны группировались.
+func summarize(sql string) (operation, collection string) {
+	s := strings.TrimSpace(lineComment.ReplaceAllString(blockComment.ReplaceAllString(sql, " "), " "))
+	if i := strings.IndexFunc(s, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '(' || r == ';' }); i >= 0 {
+		operation = strings.ToUpper(s[:i])
+	} else {
+		operation = strings.ToUpper(s)
+	}
+	if m := collectionRe.FindStringSubmatch(s); m != nil {
+		collection = strings.Trim(m[1], `"`)
+	}
+	return operation, collection
+}
+
+func spanName(operation, collection string) string {
+	switch {
+	case operation == "":
+		return "DB"
+	case collection == "":
+		return operation
+	}
+	return operation + " " + collection
+}
@@ -0,0 +1,221 @@
+package querylog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	explainableRe = regexp.MustCompile(`(?is)^\s*(?:SELECT|WITH|INSERT|UPDATE|DELETE|VALUES|TABLE)\b`)
+	dollarParamRe = regexp.MustCompile(`\$\d+`)
+	readRe        = regexp.MustCompile(`(?is)^\s*(?:SELECT|WITH|VALUES|TABLE)\b`)
+	writeRe       = regexp.MustCompile(`(?is)\b(?:INSERT|UPDATE|DELETE|MERGE|INTO|FOR\s+(?:NO\s+KEY\s+)?(?:UPDATE|SHARE|KEY\s+SHARE))\b`)
+)
+
+// explainable — EXPLAIN умеет только DML; DDL, COPY и служебные команды пропускаем
+func explainable(query string) bool {
+	return explainableRe.MatchString(query)
+}
+
+// readOnly — запрос только читает: SELECT/WITH без изменяющих команд, SELECT INTO и блокировок строк.
+// Проверка по тексту грубая (слово UPDATE в строке тоже отключит ANALYZE), поэтому ANALYZE дополнительно выполняется в транзакции READ ONLY.
+func readOnly(query string) bool {
+	return readRe.MatchString(query) && !writeRe.MatchString(query)
+}
+
+// ExplainOptions — настройки PgxExplainer
+type ExplainOptions struct {
+	// Analyze — EXPLAIN ANALYZE для читающих запросов без параметров: запрос выполняется ещё раз,
+	// зато в сводке есть фактическое число строк по узлам. Изменяющие запросы всегда без ANALYZE.
+	Analyze bool
+}
+
+// explainMode — как строится план
+type explainMode int
+
+const (
+	explainPlain    explainMode = iota // EXPLAIN (FORMAT JSON)
+	explainAnalyze                     // EXPLAIN (FORMAT JSON, ANALYZE) в транзакции READ ONLY
+	explainGeneric                     // EXPLAIN (FORMAT JSON, GENERIC_PLAN), PostgreSQL 16+
+	explainPrepared                    // PREPARE + EXPLAIN EXECUTE с NULL и force_generic_plan, PostgreSQL 12–15
+)
+
+// genericPlanVersion — первая версия с EXPLAIN (GENERIC_PLAN)
+const genericPlanVersion = 160000
+
+// preparedName — имя подготовленного запроса для explainPrepared; удаляется сразу после EXPLAIN
+const preparedName = "querylog_explain"
+
+// postgresExplain — текст EXPLAIN и способ его выполнения. С параметрами $N план строится без значений:
+// на 16+ через GENERIC_PLAN, на старых версиях — EXPLAIN EXECUTE подготовленного запроса с NULL
+// при plan_cache_mode = force_generic_plan (тот же обобщённый план). ANALYZE с параметрами невозможен.
+func postgresExplain(query string, opts ExplainOptions, serverVersion int) (string, explainMode) {
+	if n := maxParam(query); n > 0 {
+		if serverVersion >= genericPlanVersion {
+			return "EXPLAIN (FORMAT JSON, GENERIC_PLAN) " + query, explainGeneric
+		}
+		nulls := strings.TrimSuffix(strings.Repeat("NULL, ", n), ", ")
+		return fmt.Sprintf("EXPLAIN (FORMAT JSON) EXECUTE %s(%s)", preparedName, nulls), explainPrepared
+	}
+	if opts.Analyze && readOnly(query) {
+		return "EXPLAIN (FORMAT JSON, ANALYZE) " + query, explainAnalyze
+	}
+	return "EXPLAIN (FORMAT JSON) " + query, explainPlain
+}
+
+// maxParam — наибольший номер $N в запросе (0 — параметров нет)
+func maxParam(query string) int {
+	n := 0
+	for _, m := range dollarParamRe.FindAllString(query, -1) {
+		if v, err := strconv.Atoi(m[1:]); err == nil && v > n {
+			n = v
+		}
+	}
+	return n
+}
+
+// PgxExplainer строит план через пул pgx и разбирает его в Summary.
+// Версия сервера запрашивается при первом EXPLAIN и запоминается.
+func PgxExplainer(pool *pgxpool.Pool, opts ExplainOptions) Explainer {
+	var version atomic.Int64
+	return func(ctx context.Context, query string) (Plan, error) {
+		if version.Load() == 0 {
+			var v int64
+			if err := pool.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&v); err != nil {
+				return Plan{}, fmt.Errorf("ошибка определения версии сервера: %w", err)
+			}
+			version.Store(v)
+		}
+		text, mode := postgresExplain(query, opts, int(version.Load()))
+
+		var (
+			raw []byte
+			err error
+		)
+		switch mode {
+		case explainAnalyze:
+			// Запрос выполняется по-настоящему: READ ONLY не даст ему ничего изменить, а откат — оставить следы
+			err = pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+				if err := tx.QueryRow(ctx, text).Scan(&raw); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if errors.Is(err, errRollback) {
+				err = nil
+			}
+		case explainPrepared:
+			raw, err = explainPreparedPlan(ctx, pool, query, text)
+		default:
+			err = pool.QueryRow(ctx, text).Scan(&raw)
+		}
+		if err != nil {
+			return Plan{}, fmt.Errorf("ошибка EXPLAIN: %w", err)
+		}
+
+		summary, err := parsePostgresPlan(raw)
+		if err != nil {
+			return Plan{}, err
+		}
+		return Plan{Summary: summary}, nil
+	}
+}
+
+// explainPreparedPlan — обобщённый план без GENERIC_PLAN: PREPARE и EXPLAIN EXECUTE на одном соединении.
+// PREPARE не откатывается вместе с транзакцией, поэтому запрос удаляется явно.
+func explainPreparedPlan(ctx context.Context, pool *pgxpool.Pool, query, explain string) ([]byte, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	// Простой протокол: в расширенном $N внутри PREPARE сервер принял бы за параметры самого PREPARE
+	if _, err := conn.Exec(ctx, "PREPARE "+preparedName+" AS "+query, pgx.QueryExecModeSimpleProtocol); err != nil { //sqlvet:ignore PREPARE для уже выполненного запроса
+		return nil, err
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "DEALLOCATE "+preparedName); err != nil {
+			// Соединение с оставшимся запросом не должно вернуться в пул
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	var raw []byte
+	err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL plan_cache_mode = force_generic_plan"); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx, explain).Scan(&raw); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Is(err, errRollback) {
+		err = nil
+	}
+	return raw, err
+}
+
+// errRollback завершает транзакцию EXPLAIN ANALYZE откатом
+var errRollback = errors.New("откат после EXPLAIN ANALYZE")
+
+// SQLiteExplainer строит план через EXPLAIN QUERY PLAN. go-sqlite3 не выполняет запрос с недостающими
+// аргументами, поэтому сначала узнаём число параметров (NumInput) и передаём столько же NULL:
+// план от значений не зависит.
+func SQLiteExplainer(db *sql.DB) Explainer {
+	return func(ctx context.Context, query string) (Plan, error) {
+		text := "EXPLAIN QUERY PLAN " + query
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return Plan{}, err
+		}
+		defer conn.Close()
+
+		var n int
+		err = conn.Raw(func(dc any) error {
+			st, err := dc.(driver.Conn).Prepare(text)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+			n = st.NumInput()
+			return nil
+		})
+		if err != nil {
+			return Plan{}, fmt.Errorf("ошибка EXPLAIN: %w", err)
+		}
+
+		rows, err := conn.QueryContext(ctx, text, make([]any, n)...) //sqlvet:ignore EXPLAIN для уже выполненного запроса
+		if err != nil {
+			return Plan{}, fmt.Errorf("ошибка EXPLAIN: %w", err)
+		}
+		defer rows.Close()
+
+		var lines []string
+		for rows.Next() {
+			var (
+				id, parent, notUsed int
+				detail              string
+			)
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				return Plan{}, err
+			}
+			lines = append(lines, detail)
+		}
+		if err := rows.Err(); err != nil {
+			return Plan{}, err
+		}
+		return Plan{Text: strings.Join(lines, "\n"), Summary: parseSQLitePlan(query, lines)}, nil
+	}
+}
@@ -0,0 +1,163 @@
+// Package querylog — структурированный лог запросов через log/slog для pgx и database/sql.
+//
+// В лог пишутся SQL, аргументы (секреты заменены на [REDACTED]), длительность, число строк и ошибка.
+// Записи прореживаются по уровням (Options.Sample). Запросы дольше Options.Slow пишутся с уровнем WARN,
+// и к записи прикладывается план запроса с кратким разбором: полные просмотры таблиц,
+// недостающие индексы, ошибки оценки строк (Options.Explain).
+package querylog
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Explainer возвращает план запроса. Аргументы не передаются: план строится по тексту запроса,
+// чтобы значения (в том числе секреты) не уходили в базу повторно.
+type Explainer func(ctx context.Context, query string) (Plan, error)
+
+type Options struct {
+	// Slow — порог медленного запроса; 0 — не выделять медленные
+	Slow time.Duration
+	// Sample — доля записей, которая попадает в лог, по уровням: {slog.LevelInfo: 0.1}.
+	// Уровни, которых нет в карте, пишутся всегда.
+	Sample map[slog.Level]float64
+	// Secret решает, что колонка секретная; nil — DefaultSecret
+	Secret func(column string) bool
+	// Explain — план для медленных запросов (включается явно); nil — без плана
+	Explain Explainer
+	// ExplainTimeout ограничивает получение плана; 0 — 5 секунд
+	ExplainTimeout time.Duration
+}
+
+// Logger — общий для pgx и database/sql слой записи запросов
+type Logger struct {
+	log  *slog.Logger
+	opts Options
+
+	// Один EXPLAIN за раз: при лавине медленных запросов планы не должны нагружать базу ещё сильнее
+	explaining chan struct{}
+	wg         sync.WaitGroup
+}
+
+func New(log *slog.Logger, opts Options) *Logger {
+	if opts.Secret == nil {
+		opts.Secret = DefaultSecret
+	}
+	if opts.ExplainTimeout == 0 {
+		opts.ExplainTimeout = 5 * time.Second
+	}
+	return &Logger{log: log, opts: opts, explaining: make(chan struct{}, 1)}
+}
+
+// Wait дожидается записей медленных запросов, для которых ещё строится план
+func (l *Logger) Wait() {
+	l.wg.Wait()
+}
+
+type skipKey struct{}
+
+// withoutLogging помечает контекст служебных запросов (EXPLAIN), чтобы они не попадали в лог
+func withoutLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// query — одно выполнение запроса
+type query struct {
+	msg   string
+	sql   string
+	args  []any
+	took  time.Duration
+	rows  int64 // -1 — неизвестно
+	err   error
+	level slog.Level
+	attrs []slog.Attr // дополнительные поля от драйвера
+}
+
+func (l *Logger) logQuery(ctx context.Context, q query) {
+	if skipped(ctx) {
+		return
+	}
+	slow := l.opts.Slow > 0 && q.took >= l.opts.Slow
+	if slow && q.level < slog.LevelWarn {
+		q.level = slog.LevelWarn
+	}
+	if !l.log.Enabled(ctx, q.level) || !l.sampled(q.level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, len(q.attrs)+6)
+	if q.sql != "" {
+		attrs = append(attrs, slog.String("sql", q.sql))
+	}
+	if len(q.args) > 0 {
+		attrs = append(attrs, slog.Any("args", redactArgs(q.sql, q.args, l.opts.Secret)))
+	}
+	if q.took > 0 {
+		attrs = append(attrs, slog.Duration("duration", q.took))
+	}
+	if q.rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows", q.rows))
+	}
+	if q.err != nil {
+		attrs = append(attrs, slog.Any("err", q.err))
+	}
+	attrs = append(attrs, q.attrs...)
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+
+	if !slow || l.opts.Explain == nil || !explainable(q.sql) {
+		l.log.LogAttrs(ctx, q.level, q.msg, attrs...)
+		return
+	}
+	l.logWithPlan(ctx, q, attrs)
+}
+
+// logWithPlan получает план в фоне: запрос уже выполнен, а соединение ещё может быть занято им.
+// Запись появится, когда план готов, или сразу — если другой EXPLAIN ещё не закончился.
+func (l *Logger) logWithPlan(ctx context.Context, q query, attrs []slog.Attr) {
+	select {
+	case l.explaining <- struct{}{}:
+	default:
+		l.log.LogAttrs(ctx, q.level, q.msg, append(attrs, slog.String("plan_skipped", "уже выполняется другой EXPLAIN"))...)
+		return
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer func() { <-l.explaining }()
+
+		ectx, cancel := context.WithTimeout(withoutLogging(context.WithoutCancel(ctx)), l.opts.ExplainTimeout)
+		defer cancel()
+		plan, err := l.opts.Explain(ectx, q.sql)
+		if err != nil {
+			attrs = append(attrs, slog.String("plan_error", err.Error()))
+		} else {
+			if q.rows >= 0 && readOnly(q.sql) {
+				plan.Summary.checkRows(q.rows)
+			}
+			if plan.Text != "" {
+				attrs = append(attrs, slog.String("plan", plan.Text))
+			}
+			attrs = append(attrs, slog.Any("plan_summary", plan.Summary))
+		}
+		l.log.LogAttrs(ctx, q.level, q.msg, attrs...)
+	}()
+}
+
+func (l *Logger) sampled(level slog.Level) bool {
+	rate, ok := l.opts.Sample[level]
+	if !ok || rate >= 1 {
+		return true
+	}
+	return rand.Float64() < rate
+}
@@ -0,0 +1,66 @@
+package querylog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// PgxTracer — трейсер для pgx.ConnConfig.Tracer: tracelog.TraceLog, пишущий через Logger.
+// level — нижняя граница tracelog: LogLevelInfo — запросы, LogLevelDebug — ещё и Acquire/Release.
+func (l *Logger) PgxTracer(level tracelog.LogLevel) *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   pgxAdapter{l: l},
+		LogLevel: level,
+		// По умолчанию "time" — совпадает с временем записи slog
+		Config: &tracelog.TraceLogConfig{TimeKey: "duration"},
+	}
+}
+
+type pgxAdapter struct {
+	l *Logger
+}
+
+// Log раскладывает data от tracelog по полям записи: sql, args и duration — в общие поля,
+// commandTag — в rows, остальное (pid, tableName...) — как есть
+func (a pgxAdapter) Log(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	q := query{msg: msg, rows: -1, level: slogLevel(level)}
+	for k, v := range data {
+		switch k {
+		case "sql":
+			q.sql, _ = v.(string)
+		case "args":
+			q.args, _ = v.([]any)
+		case "duration":
+			q.took, _ = v.(time.Duration)
+		case "err":
+			q.err, _ = v.(error)
+		case "commandTag":
+			if s, ok := v.(string); ok && s != "" {
+				q.rows = pgconn.NewCommandTag(s).RowsAffected()
+			}
+		case "rowCount":
+			q.rows, _ = v.(int64)
+		default:
+			q.attrs = append(q.attrs, slog.Any(k, v))
+		}
+	}
+	a.l.logQuery(ctx, q)
+}
+
+func slogLevel(level tracelog.LogLevel) slog.Level {
+	switch level {
+	case tracelog.LogLevelTrace:
+		return slog.LevelDebug - 4
+	case tracelog.LogLevelDebug:
+		return slog.LevelDebug
+	case tracelog.LogLevelInfo:
+		return slog.LevelInfo
+	case tracelog.LogLevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
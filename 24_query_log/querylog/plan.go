@@ -0,0 +1,225 @@
+package querylog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Оценка строк считается ошибочной, если расходится с фактом в misestimateFactor раз
+// и не меньше чем на misestimateMinRows строк: на маленьких числах разброс неважен
+const (
+	misestimateFactor  = 10
+	misestimateMinRows = 100
+)
+
+// Plan — план медленного запроса
+type Plan struct {
+	// Text — план как есть; для FORMAT JSON пуст, в лог идёт только Summary
+	Text    string
+	Summary Summary
+}
+
+// Summary — краткий разбор плана для записи в лог
+type Summary struct {
+	// Cost — оценка стоимости всего запроса; 0 — неизвестна (SQLite)
+	Cost float64
+	// Rows — оценка строк верхнего узла; < 0 — неизвестна
+	Rows float64
+	// Analyzed — план получен с ANALYZE, в Misestimates сравнение по каждому узлу
+	Analyzed bool
+	// SeqScans — таблицы, которые читаются целиком: "accounts (строк: ~12000)"
+	SeqScans []string
+	// IndexHints — где, вероятно, не хватает индекса: "sessions: фильтр по expires_at"
+	IndexHints []string
+	// Misestimates — узлы, где оценка строк далека от факта: "Hash Join: оценка 10, факт 50000"
+	Misestimates []string
+}
+
+// LogValue — группа plan_summary с непустыми полями
+func (s Summary) LogValue() slog.Value {
+	var attrs []slog.Attr
+	if s.Cost > 0 {
+		attrs = append(attrs, slog.Float64("cost", s.Cost))
+	}
+	if s.Rows >= 0 {
+		attrs = append(attrs, slog.Float64("rows", s.Rows))
+	}
+	if s.Analyzed {
+		attrs = append(attrs, slog.Bool("analyzed", true))
+	}
+	if len(s.SeqScans) > 0 {
+		attrs = append(attrs, slog.String("seq_scans", strings.Join(s.SeqScans, "; ")))
+	}
+	if len(s.IndexHints) > 0 {
+		attrs = append(attrs, slog.String("index_hints", strings.Join(s.IndexHints, "; ")))
+	}
+	if len(s.Misestimates) > 0 {
+		attrs = append(attrs, slog.String("misestimates", strings.Join(s.Misestimates, "; ")))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// checkRows сравнивает оценку верхнего узла с числом строк, которое запрос вернул на самом деле.
+// Нужна, когда плана с ANALYZE нет: хоть что-то о точности статистики.
+func (s *Summary) checkRows(actual int64) {
+	if s.Analyzed || s.Rows < 0 {
+		return
+	}
+	if misestimated(s.Rows, float64(actual)) {
+		s.Misestimates = append(s.Misestimates, fmt.Sprintf("результат: оценка %.0f, факт %d", s.Rows, actual))
+	}
+}
+
+func misestimated(estimated, actual float64) bool {
+	lo, hi := min(estimated, actual), max(estimated, actual)
+	return hi-lo >= misestimateMinRows && hi >= misestimateFactor*math.Max(lo, 1)
+}
+
+// planNode — узел EXPLAIN (FORMAT JSON); поля Actual* есть только с ANALYZE
+type planNode struct {
+	NodeType            string     `json:"Node Type"`
+	Relation            string     `json:"Relation Name"`
+	Alias               string     `json:"Alias"`
+	Filter              string     `json:"Filter"`
+	TotalCost           float64    `json:"Total Cost"`
+	PlanRows            float64    `json:"Plan Rows"`
+	ActualRows          *float64   `json:"Actual Rows"`
+	ActualLoops         float64    `json:"Actual Loops"`
+	RowsRemovedByFilter float64    `json:"Rows Removed by Filter"`
+	Plans               []planNode `json:"Plans"`
+}
+
+// filterColumnRe — колонка слева от сравнения в условии фильтра:
+// "(email = 'x'::text)", "((status)::text = 'active'::text)", "(s.expires_at < now())"
+var filterColumnRe = regexp.MustCompile(`\(*(?:\w+\.)?(\w+)\)*(?:::[\w ]+?)?\s*(?:=|<>|!=|<=|>=|<|>|~~\*?|IS\b)`)
+
+func parsePostgresPlan(raw []byte) (Summary, error) {
+	var out []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return Summary{}, fmt.Errorf("ошибка разбора плана: %w", err)
+	}
+	if len(out) == 0 {
+		return Summary{}, fmt.Errorf("ошибка разбора плана: пустой результат EXPLAIN")
+	}
+
+	root := out[0].Plan
+	s := Summary{Cost: root.TotalCost, Rows: root.PlanRows, Analyzed: root.ActualRows != nil}
+	s.walk(root)
+	return s, nil
+}
+
+func (s *Summary) walk(n planNode) {
+	if n.NodeType == "Seq Scan" {
+		s.SeqScans = append(s.SeqScans, fmt.Sprintf("%s (строк: ~%.0f)", n.Relation, n.PlanRows))
+		if hint := indexHint(n); hint != "" {
+			s.IndexHints = append(s.IndexHints, hint)
+		}
+	}
+	if n.ActualRows != nil && misestimated(n.PlanRows, *n.ActualRows) {
+		name := n.NodeType
+		if n.Relation != "" {
+			name += " " + n.Relation
+		}
+		s.Misestimates = append(s.Misestimates, fmt.Sprintf("%s: оценка %.0f, факт %.0f", name, n.PlanRows, *n.ActualRows))
+	}
+	for _, child := range n.Plans {
+		s.walk(child)
+	}
+}
+
+// indexHint — подсказка для Seq Scan с фильтром. С ANALYZE подсказка только если фильтр
+// отбросил большую часть строк: иначе полный просмотр и так дешевле индекса.
+func indexHint(n planNode) string {
+	if n.Filter == "" {
+		return ""
+	}
+	if n.ActualRows != nil {
+		kept := *n.ActualRows * math.Max(n.ActualLoops, 1)
+		if n.RowsRemovedByFilter < misestimateMinRows || n.RowsRemovedByFilter < misestimateFactor*kept {
+			return ""
+		}
+	}
+
+	var cols []string
+	for _, m := range filterColumnRe.FindAllStringSubmatch(n.Filter, -1) {
+		if !slices.Contains(cols, m[1]) {
+			cols = append(cols, m[1])
+		}
+	}
+	if len(cols) == 0 {
+		return fmt.Sprintf("%s: фильтр %s без индекса", n.Relation, n.Filter)
+	}
+	return fmt.Sprintf("%s: фильтр по %s без индекса", n.Relation, strings.Join(cols, ", "))
+}
+
+var (
+	sqliteScanRe      = regexp.MustCompile(`^SCAN (?:TABLE )?(\S+)(?: AS \S+)?$`)
+	sqliteAutoIndexRe = regexp.MustCompile(`^SEARCH (?:TABLE )?(\S+)(?: AS \S+)? USING AUTOMATIC (?:COVERING |PARTIAL )*INDEX \(([^)]*)\)`)
+	sqliteSubqueryRe  = regexp.MustCompile(`^(?:CO-ROUTINE|MATERIALIZE) (\S+)`)
+	sqliteColumnRe    = regexp.MustCompile(`(\w+)[=<>]`)
+	fromAliasRe       = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+(\w+)(?:\s+(?:AS\s+)?(\w+))?`)
+)
+
+// notAlias — ключевые слова, которые fromAliasRe может принять за псевдоним
+var notAlias = map[string]bool{
+	"ON": true, "USING": true, "WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true,
+	"FULL": true, "CROSS": true, "NATURAL": true, "GROUP": true, "ORDER": true, "LIMIT": true, "UNION": true,
+}
+
+// tableAliases — псевдоним -> таблица из FROM и JOIN: SQLite называет в плане псевдонимы
+func tableAliases(query string) map[string]string {
+	aliases := make(map[string]string)
+	for _, m := range fromAliasRe.FindAllStringSubmatch(query, -1) {
+		if m[2] != "" && !notAlias[strings.ToUpper(m[2])] {
+			aliases[m[2]] = m[1]
+		}
+	}
+	return aliases
+}
+
+// parseSQLitePlan разбирает строки EXPLAIN QUERY PLAN. Оценок строк SQLite не даёт,
+// зато автоматический индекс прямо указывает на недостающий.
+func parseSQLitePlan(query string, lines []string) Summary {
+	s := Summary{Rows: -1}
+	aliases := tableAliases(query)
+	table := func(name string) string {
+		if t, ok := aliases[name]; ok {
+			return t
+		}
+		return name
+	}
+
+	// SCAN по CTE и подзапросам — не таблицы
+	subqueries := make(map[string]bool)
+	for _, l := range lines {
+		if m := sqliteSubqueryRe.FindStringSubmatch(l); m != nil {
+			subqueries[m[1]] = true
+		}
+	}
+
+	for _, l := range lines {
+		switch {
+		case sqliteScanRe.MatchString(l):
+			if name := sqliteScanRe.FindStringSubmatch(l)[1]; !subqueries[name] {
+				s.SeqScans = append(s.SeqScans, table(name))
+			}
+		case sqliteAutoIndexRe.MatchString(l):
+			m := sqliteAutoIndexRe.FindStringSubmatch(l)
+			var cols []string
+			for _, c := range sqliteColumnRe.FindAllStringSubmatch(m[2], -1) {
+				cols = append(cols, c[1])
+			}
+			s.IndexHints = append(s.IndexHints, fmt.Sprintf("%s: автоматический индекс по %s", table(m[1]), strings.Join(cols, ", ")))
+		case strings.HasPrefix(l, "USE TEMP B-TREE FOR"):
+			s.IndexHints = append(s.IndexHints, "сортировка во временном B-дереве: "+strings.TrimPrefix(l, "USE TEMP B-TREE FOR "))
+		}
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+package querylog
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Redacted — чем заменяется секретное значение в логе
+const Redacted = "[REDACTED]"
+
+// DefaultSecret — колонки с паролями, токенами и хешами: password, password_hash, token_hash, api_secret...
+func DefaultSecret(column string) bool {
+	c := strings.ToLower(strings.Trim(column, `"`))
+	if i := strings.LastIndexByte(c, '.'); i >= 0 {
+		c = c[i+1:]
+	}
+	return strings.Contains(c, "password") || strings.Contains(c, "secret") ||
+		strings.Contains(c, "token") || strings.HasSuffix(c, "_hash")
+}
+
+var (
+	// col = $1, a.col <> ?, col LIKE $2
+	comparisonRe = regexp.MustCompile(`(?i)([\w."]+)\s*(?:=|<>|!=|<=|>=|<|>|\bLIKE\b|\bILIKE\b)\s*(\$\d+|\?)`)
+	insertRe     = regexp.MustCompile(`(?is)\bINSERT\s+INTO\s+[\w."]+\s*\(([^)]*)\)\s*VALUES\s*`)
+)
+
+// redactArgs заменяет значения параметров, которые попадают в секретные колонки.
+// Колонку параметра находим по тексту запроса: INSERT INTO t (a, b) VALUES ($1, $2)
+// и сравнения вида col = $1. Именованные аргументы (pgx.NamedArgs, sql.Named) — по имени.
+func redactArgs(query string, args []any, secret func(string) bool) []any {
+	if len(args) == 0 {
+		return args
+	}
+	out := make([]any, len(args))
+	copy(out, args)
+
+	for i, a := range out {
+		switch v := a.(type) {
+		case pgx.NamedArgs:
+			masked := make(pgx.NamedArgs, len(v))
+			for k, val := range v {
+				if secret(k) {
+					val = Redacted
+				}
+				masked[k] = val
+			}
+			out[i] = masked
+		case sql.NamedArg:
+			if secret(v.Name) {
+				out[i] = sql.Named(v.Name, Redacted)
+			}
+		}
+	}
+
+	for ordinal, column := range paramColumns(query) {
+		if ordinal >= 1 && ordinal <= len(out) && secret(column) {
+			out[ordinal-1] = Redacted
+		}
+	}
+	return out
+}
+
+// paramColumns — номер параметра (с 1) -> колонка, в которую он попадает
+func paramColumns(query string) map[int]string {
+	ordinals := placeholders(query)
+	cols := make(map[int]string)
+
+	for _, m := range comparisonRe.FindAllStringSubmatchIndex(query, -1) {
+		if n, ok := ordinals[m[4]]; ok {
+			cols[n] = query[m[2]:m[3]]
+		}
+	}
+
+	if m := insertRe.FindStringSubmatchIndex(query); m != nil {
+		names := strings.Split(query[m[2]:m[3]], ",")
+		for _, tuple := range valueTuples(query, m[1]) {
+			for k, pos := range tuple {
+				if n, ok := ordinals[pos]; ok && k < len(names) {
+					cols[n] = strings.TrimSpace(names[k])
+				}
+			}
+		}
+	}
+	return cols
+}
+
+// placeholders — позиция в тексте -> номер параметра. $N берёт номер из текста,
+// ? нумеруется по порядку. Строки в кавычках пропускаются.
+func placeholders(query string) map[int]int {
+	pos := make(map[int]int)
+	next := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			// '' внутри строки — экранированная кавычка, цикл перешагнёт её как конец и начало
+			for i++; i < len(query) && query[i] != '\''; i++ {
+			}
+		case c == '?':
+			next++
+			pos[i] = next
+		case c == '$':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, _ := strconv.Atoi(query[i+1 : j])
+				pos[i] = n
+				i = j - 1
+			}
+		}
+	}
+	return pos
+}
+
+// valueTuples — для каждого кортежа VALUES (...), (...) позиции его элементов
+func valueTuples(query string, start int) [][]int {
+	var tuples [][]int
+	i := start
+	for {
+		for i < len(query) && strings.IndexByte(" \n\t,", query[i]) >= 0 {
+			i++
+		}
+		if i >= len(query) || query[i] != '(' {
+			return tuples
+		}
+		elems, end, ok := tupleElems(query, i)
+		if !ok {
+			return tuples
+		}
+		tuples = append(tuples, elems)
+		i = end
+	}
+}
+
+// tupleElems разбирает кортеж, начинающийся с '(' в позиции open:
+// позиции элементов и позиция сразу за закрывающей скобкой
+func tupleElems(query string, open int) (elems []int, end int, ok bool) {
+	depth := 0
+	elemStart := true
+	for i := open + 1; i < len(query); i++ {
+		c := query[i]
+		if elemStart && strings.IndexByte(" \n\t", c) < 0 {
+			elems = append(elems, i)
+			elemStart = false
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return elems, i + 1, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				elemStart = true
+			}
+		case '\'':
+			for i++; i < len(query) && query[i] != '\''; i++ {
+			}
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,182 @@
+package querylog
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// DB — *sql.DB, каждый запрос которого пишется в Logger
+type DB struct {
+	db *sql.DB
+	l  *Logger
+}
+
+func (l *Logger) WrapDB(db *sql.DB) *DB {
+	return &DB{db: db, l: l}
+}
+
+// DB — исходный *sql.DB для кода, который логировать не нужно
+func (d *DB) DB() *sql.DB {
+	return d.db
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execLogged(ctx, d.l, d.db, query, args)
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	return queryLogged(ctx, d.l, d.db, query, args)
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return queryRowLogged(ctx, d.l, d.db, query, args)
+}
+
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, l: d.l, ctx: ctx}, nil
+}
+
+// Tx — транзакция с теми же записями в лог; Commit и Rollback тоже пишутся
+type Tx struct {
+	tx  *sql.Tx
+	l   *Logger
+	ctx context.Context
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execLogged(ctx, t.l, t.tx, query, args)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	return queryLogged(ctx, t.l, t.tx, query, args)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return queryRowLogged(ctx, t.l, t.tx, query, args)
+}
+
+func (t *Tx) Commit() error {
+	start := time.Now()
+	err := t.tx.Commit()
+	t.l.logQuery(t.ctx, query{msg: "Commit", took: time.Since(start), rows: -1, err: err, level: levelFor(err)})
+	return err
+}
+
+func (t *Tx) Rollback() error {
+	start := time.Now()
+	err := t.tx.Rollback()
+	t.l.logQuery(t.ctx, query{msg: "Rollback", took: time.Since(start), rows: -1, err: err, level: levelFor(err)})
+	return err
+}
+
+// querier — общее у *sql.DB и *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func execLogged(ctx context.Context, l *Logger, q querier, sqlText string, args []any) (sql.Result, error) {
+	start := time.Now()
+	res, err := q.ExecContext(ctx, sqlText, args...)
+	rec := query{msg: "Exec", sql: sqlText, args: args, took: time.Since(start), rows: -1, err: err, level: levelFor(err)}
+	if err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			rec.rows = n
+		}
+	}
+	l.logQuery(ctx, rec)
+	return res, err
+}
+
+func queryLogged(ctx context.Context, l *Logger, q querier, sqlText string, args []any) (*Rows, error) {
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, sqlText, args...)
+	if err != nil {
+		l.logQuery(ctx, query{msg: "Query", sql: sqlText, args: args, took: time.Since(start), rows: -1, err: err, level: slog.LevelError})
+		return nil, err
+	}
+	return &Rows{Rows: rows, l: l, ctx: ctx, rec: query{msg: "Query", sql: sqlText, args: args}, start: start}, nil
+}
+
+func queryRowLogged(ctx context.Context, l *Logger, q querier, sqlText string, args []any) *Row {
+	return &Row{row: q.QueryRowContext(ctx, sqlText, args...), l: l, ctx: ctx,
+		rec: query{msg: "QueryRow", sql: sqlText, args: args}, start: time.Now()}
+}
+
+// Rows пишет запрос в лог, когда строки прочитаны или закрыты: длительность — до конца чтения
+type Rows struct {
+	*sql.Rows
+	l      *Logger
+	ctx    context.Context
+	rec    query
+	start  time.Time
+	n      int64
+	logged bool
+}
+
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		r.n++
+		return true
+	}
+	r.done(r.Rows.Err())
+	return false
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.done(err)
+	return err
+}
+
+func (r *Rows) done(err error) {
+	if r.logged {
+		return
+	}
+	r.logged = true
+	r.rec.took = time.Since(r.start)
+	r.rec.rows = r.n
+	r.rec.err = err
+	r.rec.level = levelFor(err)
+	r.l.logQuery(r.ctx, r.rec)
+}
+
+// Row — ошибка запроса видна только в Scan, поэтому и запись делается в Scan
+type Row struct {
+	row   *sql.Row
+	l     *Logger
+	ctx   context.Context
+	rec   query
+	start time.Time
+}
+
+func (r *Row) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.rec.took = time.Since(r.start)
+	r.rec.err = err
+	r.rec.rows = 1
+	if err != nil {
+		r.rec.rows = 0
+	}
+	// sql.ErrNoRows — обычный результат, а не сбой запроса
+	r.rec.level = levelFor(err)
+	if err == sql.ErrNoRows {
+		r.rec.level = slog.LevelInfo
+	}
+	r.l.logQuery(r.ctx, r.rec)
+	return err
+}
+
+func levelFor(err error) slog.Level {
+	if err != nil {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
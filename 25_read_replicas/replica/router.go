@@ -0,0 +1,282 @@
+// Package replica — маршрутизатор запросов между primary и репликами PostgreSQL поверх pgxpool.
+//
+// Запись (Exec, изменяющие запросы, транзакции на запись) идёт на primary.
+// Чтение (SELECT без блокировок, транзакции ReadOnly) — на реплику по выбранной балансировке.
+// Если реплика недоступна, запрос повторяется на primary, а реплика пропускается Options.Cooldown.
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Balancing — как выбирается реплика для чтения
+type Balancing int
+
+const (
+	// RoundRobin — по очереди
+	RoundRobin Balancing = iota
+	// LeastConns — реплика с наименьшим числом занятых соединений
+	LeastConns
+)
+
+type Options struct {
+	Balancing Balancing
+	// Cooldown — сколько не отправлять запросы на реплику после ошибки соединения; 0 — 5 секунд
+	Cooldown time.Duration
+}
+
+// Stats — счётчики маршрутизации
+type Stats struct {
+	Writes       uint64
+	PrimaryReads uint64
+	ReplicaReads uint64
+	// Fallbacks — чтения, повторённые на primary из-за ошибки реплики
+	Fallbacks uint64
+}
+
+type node struct {
+	pool *pgxpool.Pool
+	// downUntil — до какого момента (UnixNano) реплика считается недоступной
+	downUntil atomic.Int64
+}
+
+func (n *node) healthy(now time.Time) bool {
+	return n.downUntil.Load() <= now.UnixNano()
+}
+
+// Router безопасен для конкурентного использования. Пулы принадлежат ему: Close закрывает все.
+type Router struct {
+	primary  *pgxpool.Pool
+	replicas []*node
+	opts     Options
+	next     atomic.Uint64
+
+	writes, primaryReads, replicaReads, fallbacks atomic.Uint64
+}
+
+func New(primary *pgxpool.Pool, replicas []*pgxpool.Pool, opts Options) *Router {
+	if opts.Cooldown == 0 {
+		opts.Cooldown = 5 * time.Second
+	}
+	r := &Router{primary: primary, opts: opts}
+	for _, p := range replicas {
+		r.replicas = append(r.replicas, &node{pool: p})
+	}
+	return r
+}
+
+// Open создаёт пулы по строкам подключения. Пулы pgx подключаются лениво,
+// поэтому недоступная реплика не мешает старту — она отсеется при первом запросе.
+func Open(ctx context.Context, primaryDSN string, replicaDSNs []string, opts Options) (*Router, error) {
+	primary, err := pgxpool.New(ctx, primaryDSN)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пул primary: %w", err)
+	}
+	var replicas []*pgxpool.Pool
+	for _, dsn := range replicaDSNs {
+		p, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			primary.Close()
+			for _, p := range replicas {
+				p.Close()
+			}
+			return nil, fmt.Errorf("не удалось создать пул реплики: %w", err)
+		}
+		replicas = append(replicas, p)
+	}
+	return New(primary, replicas, opts), nil
+}
+
+// Primary — пул primary для кода, которому маршрутизация не нужна (миграции, COPY)
+func (r *Router) Primary() *pgxpool.Pool {
+	return r.primary
+}
+
+func (r *Router) Close() {
+	r.primary.Close()
+	for _, n := range r.replicas {
+		n.pool.Close()
+	}
+}
+
+func (r *Router) Stats() Stats {
+	return Stats{
+		Writes:       r.writes.Load(),
+		PrimaryReads: r.primaryReads.Load(),
+		ReplicaReads: r.replicaReads.Load(),
+		Fallbacks:    r.fallbacks.Load(),
+	}
+}
+
+// Exec всегда выполняется на primary
+func (r *Router) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.wrote(ctx)
+	return r.primary.Exec(ctx, sql, args...)
+}
+
+// Query — чтение на реплике, изменяющие запросы (INSERT ... RETURNING) на primary.
+// Переход на primary возможен, только пока строки не начали читаться:
+// обрыв соединения посреди чтения вернётся из rows.Err().
+func (r *Router) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !isRead(sql) {
+		r.wrote(ctx)
+		return r.primary.Query(ctx, sql, args...)
+	}
+	if n := r.replicaFor(ctx); n != nil {
+		rows, err := n.pool.Query(ctx, sql, args...)
+		if err == nil {
+			r.replicaReads.Add(1)
+			return rows, nil
+		}
+		if !r.fallback(ctx, n, err) {
+			return nil, err
+		}
+	}
+	r.primaryReads.Add(1)
+	return r.primary.Query(ctx, sql, args...)
+}
+
+// QueryRow — как Query; запрос выполняется в Scan, там же и переход на primary
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &row{r: r, ctx: ctx, sql: sql, args: args}
+}
+
+type row struct {
+	r    *Router
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (x *row) Scan(dest ...any) error {
+	if !isRead(x.sql) {
+		x.r.wrote(x.ctx)
+		return x.r.primary.QueryRow(x.ctx, x.sql, x.args...).Scan(dest...)
+	}
+	if n := x.r.replicaFor(x.ctx); n != nil {
+		err := n.pool.QueryRow(x.ctx, x.sql, x.args...).Scan(dest...)
+		if err == nil || errors.Is(err, pgx.ErrNoRows) {
+			x.r.replicaReads.Add(1)
+			return err
+		}
+		if !x.r.fallback(x.ctx, n, err) {
+			return err
+		}
+	}
+	x.r.primaryReads.Add(1)
+	return x.r.primary.QueryRow(x.ctx, x.sql, x.args...).Scan(dest...)
+}
+
+// BeginTx — транзакция ReadOnly на реплике, остальные на primary.
+// Router подходит для pgx.BeginTxFunc.
+func (r *Router) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	if opts.AccessMode != pgx.ReadOnly {
+		r.wrote(ctx)
+		return r.primary.BeginTx(ctx, opts)
+	}
+	if n := r.replicaFor(ctx); n != nil {
+		tx, err := n.pool.BeginTx(ctx, opts)
+		if err == nil {
+			r.replicaReads.Add(1)
+			return tx, nil
+		}
+		if !r.fallback(ctx, n, err) {
+			return nil, err
+		}
+	}
+	r.primaryReads.Add(1)
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// replicaFor — реплика для чтения или nil, если читать нужно с primary
+func (r *Router) replicaFor(ctx context.Context) *node {
+	if Pinned(ctx) {
+		return nil
+	}
+	return r.pick(time.Now())
+}
+
+// pick выбирает доступную реплику; nil — доступных нет
+func (r *Router) pick(now time.Time) *node {
+	if len(r.replicas) == 0 {
+		return nil
+	}
+	// Обход начинается со сдвига: в RoundRobin это очередь, в LeastConns — выбор среди равных
+	start := int(r.next.Add(1) % uint64(len(r.replicas)))
+
+	var best *node
+	var bestConns int32
+	for i := range r.replicas {
+		n := r.replicas[(start+i)%len(r.replicas)]
+		if !n.healthy(now) {
+			continue
+		}
+		if r.opts.Balancing == RoundRobin {
+			return n
+		}
+		if conns := n.pool.Stat().AcquiredConns(); best == nil || conns < bestConns {
+			best, bestConns = n, conns
+		}
+	}
+	return best
+}
+
+// fallback решает, повторять ли чтение на primary после ошибки реплики.
+// Недоступная реплика на Cooldown выключается; ошибки самого запроса (синтаксис, нет таблицы)
+// повторять бессмысленно — они вернутся вызывающему коду.
+func (r *Router) fallback(ctx context.Context, n *node, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "25006":
+			// read_only_sql_transaction: запрос на самом деле пишет, реплика тут ни при чём
+		case pgErr.Code == "40001":
+			// Конфликт с восстановлением: реплика отменила запрос из-за применяемого WAL
+		case len(pgErr.Code) == 5 && pgErr.Code[:3] == "57P":
+			// admin_shutdown, cannot_connect_now — реплика останавливается или ещё не готова
+			r.markDown(n)
+		default:
+			return false
+		}
+		r.fallbacks.Add(1)
+		return true
+	}
+
+	var netErr net.Error
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		r.markDown(n)
+		r.fallbacks.Add(1)
+		return true
+	}
+	return false
+}
+
+func (r *Router) markDown(n *node) {
+	n.downUntil.Store(time.Now().Add(r.opts.Cooldown).UnixNano())
+}
+
+var (
+	readRe  = regexp.MustCompile(`(?is)^\s*(?:SELECT|WITH|VALUES|TABLE|SHOW)\b`)
+	writeRe = regexp.MustCompile(`(?is)\b(?:INSERT|UPDATE|DELETE|MERGE|INTO|NEXTVAL|SETVAL|FOR\s+(?:NO\s+KEY\s+)?(?:UPDATE|SHARE|KEY\s+SHARE))\b`)
+)
+
+// isRead — запрос можно отправить на реплику. Проверка по тексту осторожная:
+// любое подозрительное слово отправляет запрос на primary. Функции с побочными эффектами
+// по тексту не видны — для них есть UsePrimary.
+func isRead(sql string) bool {
+	return readRe.MatchString(sql) && !writeRe.MatchString(sql)
+}
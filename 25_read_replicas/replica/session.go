@@ -0,0 +1,41 @@
+package replica
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+
+// session — состояние одного запроса к приложению (HTTP-запроса, задачи)
+type session struct {
+	pinned atomic.Bool
+}
+
+// ReadYourWrites включает режим "читаю свои записи": после первой записи в этом контексте
+// все чтения идут на primary, чтобы не получить с реплики данные до записи из-за отставания.
+// Контекст создаётся на запрос к приложению, например в HTTP middleware.
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// UsePrimary отправляет все запросы контекста на primary
+func UsePrimary(ctx context.Context) context.Context {
+	s := &session{}
+	s.pinned.Store(true)
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// Pinned — запросы контекста уже закреплены за primary
+func Pinned(ctx context.Context) bool {
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	return s != nil && s.pinned.Load()
+}
+
+// wrote учитывает запись и закрепляет сессию за primary
+func (r *Router) wrote(ctx context.Context) {
+	r.writes.Add(1)
+	if s, _ := ctx.Value(sessionKey{}).(*session); s != nil {
+		s.pinned.Store(true)
+	}
+}
@@ -0,0 +1,293 @@
+// Package failover — пул pgx, который следует за primary при переключении кластера.
+//
+// Строка подключения может содержать несколько хостов: host1:5432,host2:5432.
+// pgx перебирает их по порядку и с target_session_attrs=read-write (по умолчанию здесь)
+// подключается только к серверу, принимающему запись. Проверка pg_is_in_recovery() раз в
+// Options.CheckInterval замечает, что primary стал репликой или пропал, — тогда пул
+// пересоздаётся и находит новый primary.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/puddle/v2"
+)
+
+var ErrNoPrimary = errors.New("primary не найден ни на одном хосте")
+
+type Options struct {
+	// CheckInterval — период проверки primary; 0 — 2 секунды
+	CheckInterval time.Duration
+	// ConnectTimeout ограничивает поиск нового primary по всем хостам; 0 — 10 секунд
+	ConnectTimeout time.Duration
+	// Logger — журнал событий переключения; nil — slog.Default()
+	Logger *slog.Logger
+	// OnFailover вызывается после переключения — например, для метрик Prometheus
+	OnFailover func(Event)
+}
+
+// Event — одно переключение на новый primary
+type Event struct {
+	From, To string
+	Reason   string
+	At       time.Time
+	// Took — от обнаружения проблемы до готового пула
+	Took time.Duration
+}
+
+// Stats — счётчики для метрик
+type Stats struct {
+	Primary      string
+	Checks       uint64
+	CheckErrors  uint64
+	Failovers    uint64
+	LastFailover time.Time
+}
+
+// Pool безопасен для конкурентного использования
+type Pool struct {
+	cfg  *pgxpool.Config
+	opts Options
+	log  *slog.Logger
+	// primaryOnly — пул ждёт только primary (read-write или primary): сервер в recovery — это переключение
+	primaryOnly bool
+
+	cur atomic.Pointer[current]
+	// mu — одно пересоздание пула за раз
+	mu sync.Mutex
+
+	nudge  chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	checks, checkErrors, failovers atomic.Uint64
+	lastFailover                   atomic.Int64
+}
+
+type current struct {
+	pool *pgxpool.Pool
+	addr string
+}
+
+// Open подключается к primary и запускает проверку в фоне.
+// target_session_attrs из строки подключения или PGTARGETSESSIONATTRS сохраняется (в том числе any);
+// если не задан — read-write.
+func Open(ctx context.Context, dsn string, opts Options) (*Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора DSN: %w", err)
+	}
+	// По ValidateConnect == nil не отличить «не задан» от явного any — смотрим на сам параметр
+	attrs := targetSessionAttrs(dsn)
+	if attrs == "" {
+		attrs = "read-write"
+		cfg.ConnConfig.ValidateConnect = pgconn.ValidateConnectTargetSessionAttrsReadWrite
+	}
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = 2 * time.Second
+	}
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	p := &Pool{cfg: cfg, opts: opts, log: opts.Logger, nudge: make(chan struct{}, 1), done: make(chan struct{}),
+		primaryOnly: attrs == "read-write" || attrs == "primary"}
+	cur, err := p.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.cur.Store(cur)
+	p.log.Info("подключение к primary", "primary", cur.addr)
+
+	wctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.watch(wctx)
+	return p, nil
+}
+
+var targetAttrsKeyRe = regexp.MustCompile(`(?:^|\s)target_session_attrs\s*=\s*'?([\w-]*)`)
+
+// targetSessionAttrsSet — задан ли target_session_attrs в DSN (URL или key=value) или в окружении
+func targetSessionAttrsSet(dsn string) bool {
+	return targetSessionAttrs(dsn) != ""
+}
+
+// targetSessionAttrs — значение target_session_attrs: из DSN, иначе из PGTARGETSESSIONATTRS; "" — не задан
+func targetSessionAttrs(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		// url.Parse не разберёт несколько хостов с портами (h1:5432,h2:5432) — читаем только параметры
+		_, query, _ := strings.Cut(dsn, "?")
+		if q, err := url.ParseQuery(query); err == nil && q.Has("target_session_attrs") {
+			return q.Get("target_session_attrs")
+		}
+	} else if m := targetAttrsKeyRe.FindStringSubmatch(dsn); m != nil {
+		return m[1]
+	}
+	return os.Getenv("PGTARGETSESSIONATTRS")
+}
+
+// connect создаёт пул и дожидается первого соединения: pgx выбирает хост по target_session_attrs
+func (p *Pool) connect(ctx context.Context) (*current, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ConnectTimeout)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, p.cfg.Copy())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пул: %w", err)
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("%w: %w", ErrNoPrimary, err)
+	}
+	addr := conn.Conn().PgConn().Conn().RemoteAddr().String()
+	conn.Release()
+	return &current{pool: pool, addr: addr}, nil
+}
+
+// Pool — текущий пул. После переключения старый закрывается:
+// держите ссылку только на время одной операции.
+func (p *Pool) Pool() *pgxpool.Pool {
+	return p.cur.Load().pool
+}
+
+func (p *Pool) Stats() Stats {
+	s := Stats{
+		Primary:     p.cur.Load().addr,
+		Checks:      p.checks.Load(),
+		CheckErrors: p.checkErrors.Load(),
+		Failovers:   p.failovers.Load(),
+	}
+	if ns := p.lastFailover.Load(); ns != 0 {
+		s.LastFailover = time.Unix(0, ns)
+	}
+	return s
+}
+
+// Close останавливает проверку и закрывает текущий пул
+func (p *Pool) Close() {
+	p.cancel()
+	<-p.done
+	p.cur.Load().pool.Close()
+}
+
+func (p *Pool) watch(ctx context.Context) {
+	defer close(p.done)
+	t := time.NewTicker(p.opts.CheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-p.nudge:
+		}
+		p.Check(ctx)
+	}
+}
+
+// Check проверяет, что текущий сервер отвечает и всё ещё primary, и переключается, если нет.
+// С явным target_session_attrs any, standby, prefer-standby или read-only реплика — допустимый
+// сервер: pg_is_in_recovery() = true переключением не считается, проверяется только доступность
+func (p *Pool) Check(ctx context.Context) {
+	p.checks.Add(1)
+	cur := p.cur.Load()
+
+	cctx, cancel := context.WithTimeout(ctx, p.opts.CheckInterval)
+	var inRecovery bool
+	err := cur.pool.QueryRow(cctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	switch {
+	case err != nil:
+		p.checkErrors.Add(1)
+		p.failover(ctx, cur, fmt.Sprintf("primary не отвечает: %v", err))
+	case inRecovery && p.primaryOnly:
+		p.failover(ctx, cur, "primary стал репликой (pg_is_in_recovery)")
+	}
+}
+
+func (p *Pool) failover(ctx context.Context, old *current, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Пока ждали блокировку, пул мог уже пересоздать другой вызов
+	if p.cur.Load() != old {
+		return
+	}
+
+	start := time.Now()
+	p.log.Warn("поиск нового primary", "primary", old.addr, "reason", reason)
+	next, err := p.connect(ctx)
+	if err != nil {
+		p.log.Error("переключение не удалось", "primary", old.addr, "err", err)
+		return
+	}
+	p.cur.Store(next)
+	// Сбой проверки мог быть временным: пул пересоздан на том же сервере — это не переключение
+	if next.addr == old.addr {
+		p.log.Info("переподключение к тому же primary", "primary", next.addr, "reason", reason)
+		go old.pool.Close()
+		return
+	}
+
+	ev := Event{From: old.addr, To: next.addr, Reason: reason, At: time.Now(), Took: time.Since(start)}
+	p.failovers.Add(1)
+	p.lastFailover.Store(ev.At.UnixNano())
+	p.log.Warn("переключение на новый primary", "from", ev.From, "to", ev.To, "reason", ev.Reason, "took", ev.Took)
+	if p.opts.OnFailover != nil {
+		p.opts.OnFailover(ev)
+	}
+
+	// Close ждёт возврата всех соединений — не блокируем проверку
+	go old.pool.Close()
+}
+
+// notify просит проверить primary вне очереди, если ошибка похожа на переключение
+func (p *Pool) notify(err error) {
+	if err == nil || !failoverError(err) {
+		return
+	}
+	select {
+	case p.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// failoverError — ошибка соединения или сервер больше не принимает запись
+func failoverError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "25006", // read_only_sql_transaction: запись на реплику
+			"57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+		return false
+	}
+	var (
+		connErr *pgconn.ConnectError
+		netErr  net.Error
+	)
+	return errors.As(err, &connErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, puddle.ErrClosedPool)
+}
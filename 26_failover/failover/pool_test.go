@@ -0,0 +1,71 @@
+package failover
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
+)
+
+func TestTargetSessionAttrs(t *testing.T) {
+	tests := []struct {
+		dsn, env, want string
+	}{
+		{dsn: "postgres://u:p@h1:5432,h2:5432/db?sslmode=disable", want: ""},
+		{dsn: "postgres://u:p@h1:5432,h2:5432/db?target_session_attrs=any", want: "any"},
+		{dsn: "postgresql://u:p@h1/db?connect_timeout=2&target_session_attrs=prefer-standby", want: "prefer-standby"},
+		{dsn: "host=h1,h2 dbname=db", want: ""},
+		{dsn: "host=h1,h2 target_session_attrs=read-only dbname=db", want: "read-only"},
+		{dsn: "target_session_attrs = 'standby' host=h1", want: "standby"},
+		// Похожее имя параметра — не target_session_attrs
+		{dsn: "host=h1 my_target_session_attrs=any", want: ""},
+		{dsn: "postgres://u:p@h1/db", env: "standby", want: "standby"},
+		{dsn: "host=h1", env: "any", want: "any"},
+		// Параметр в DSN важнее окружения, как и в pgconn.ParseConfig
+		{dsn: "postgres://u:p@h1/db?target_session_attrs=primary", env: "standby", want: "primary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			t.Setenv("PGTARGETSESSIONATTRS", tt.env)
+			if got := targetSessionAttrs(tt.dsn); got != tt.want {
+				t.Errorf("targetSessionAttrs = %q, ожидалось %q", got, tt.want)
+			}
+			if got := targetSessionAttrsSet(tt.dsn); got != (tt.want != "") {
+				t.Errorf("targetSessionAttrsSet = %v", got)
+			}
+		})
+	}
+}
+
+func TestFailoverError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"запись на реплику", &pgconn.PgError{Code: "25006"}, true},
+		{"admin_shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"crash_shutdown", &pgconn.PgError{Code: "57P02"}, true},
+		{"cannot_connect_now", &pgconn.PgError{Code: "57P03"}, true},
+		{"обёрнутая ошибка сервера", fmt.Errorf("ошибка запроса: %w", &pgconn.PgError{Code: "25006"}), true},
+		{"нарушение уникальности", &pgconn.PgError{Code: "23505"}, false},
+		{"отмена запроса", &pgconn.PgError{Code: "57014"}, false},
+		{"ошибка подключения", &pgconn.ConnectError{}, true},
+		{"сетевая ошибка", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true},
+		{"обрыв соединения", io.ErrUnexpectedEOF, true},
+		{"EOF", fmt.Errorf("ошибка чтения: %w", io.EOF), true},
+		{"закрытый пул", puddle.ErrClosedPool, true},
+		{"прочая ошибка", errors.New("no rows in result set"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failoverError(tt.err); got != tt.want {
+				t.Errorf("failoverError(%v) = %v, ожидалось %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
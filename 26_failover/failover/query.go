@@ -0,0 +1,46 @@
+package failover
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Запросы идут в текущий пул. Ошибка, похожая на переключение, запускает проверку сразу,
+// не дожидаясь CheckInterval. Повтора нет: запись могла успеть выполниться,
+// решать о повторе должен вызывающий код.
+
+func (p *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := p.Pool().Exec(ctx, sql, args...)
+	p.notify(err)
+	return tag, err
+}
+
+func (p *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := p.Pool().Query(ctx, sql, args...)
+	p.notify(err)
+	return rows, err
+}
+
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &row{p: p, row: p.Pool().QueryRow(ctx, sql, args...)}
+}
+
+type row struct {
+	p   *Pool
+	row pgx.Row
+}
+
+func (r *row) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.p.notify(err)
+	return err
+}
+
+// BeginTx — Pool подходит для pgx.BeginTxFunc
+func (p *Pool) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := p.Pool().BeginTx(ctx, opts)
+	p.notify(err)
+	return tx, err
+}
@@ -0,0 +1,203 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+// Реестр арендаторов — в public: по нему MigrateAll находит все схемы
+const registryDDL = `CREATE TABLE IF NOT EXISTS public.tenants (
+	id TEXT PRIMARY KEY,
+	schema_name TEXT UNIQUE NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// extensions — расширения, которые создают общие миграции. Provision и MigrateAll заранее ставят их
+// в ExtensionsSchema: иначе CREATE EXTENSION из миграции поставит расширение в схему первого арендатора
+var extensions = []string{"pgcrypto"}
+
+// Report — состояние миграций одного арендатора
+type Report struct {
+	Tenant string
+	Schema string
+	// Before и After — версия схемы до и после; Target — последняя версия в миграциях
+	Before, After, Target int64
+	Applied               []int64
+	Took                  time.Duration
+	Err                   error
+}
+
+func (r Report) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: ❌ версия %d из %d: %v", r.Tenant, r.After, r.Target, r.Err)
+	case len(r.Applied) > 0:
+		return fmt.Sprintf("%s: ✅ %d -> %d, применено %v за %s", r.Tenant, r.Before, r.After, r.Applied, r.Took.Round(time.Millisecond))
+	case r.After < r.Target:
+		return fmt.Sprintf("%s: ⏳ версия %d из %d", r.Tenant, r.After, r.Target)
+	}
+	return fmt.Sprintf("%s: ✅ версия %d, актуальна", r.Tenant, r.After)
+}
+
+// Migrator создаёт схемы арендаторов и применяет к ним миграции goose.
+// Таблица версий goose_db_version своя в каждой схеме.
+type Migrator struct {
+	pool *pgxpool.Pool
+	fsys fs.FS
+}
+
+// NewMigrator — fsys с файлами миграций в корне (fs.Sub для embed.FS)
+func NewMigrator(pool *pgxpool.Pool, fsys fs.FS) *Migrator {
+	return &Migrator{pool: pool, fsys: fsys}
+}
+
+// Provision создаёт схему арендатора, записывает его в реестр и применяет миграции.
+// Повторный вызов безопасен: дозапускает миграции, если прошлый раз прервался.
+func (m *Migrator) Provision(ctx context.Context, id string) (Report, error) {
+	schema, err := Schema(id)
+	if err != nil {
+		return Report{Tenant: id}, err
+	}
+	if _, err := m.pool.Exec(ctx, registryDDL); err != nil {
+		return Report{Tenant: id}, fmt.Errorf("ошибка создания реестра арендаторов: %w", err)
+	}
+	//sqlvet:ignore имя схемы проверено Schema и экранировано ident.Quote
+	if _, err := m.pool.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+ident.Quote(ident.Postgres, schema)); err != nil {
+		return Report{Tenant: id}, fmt.Errorf("ошибка создания схемы %s: %w", schema, err)
+	}
+	if _, err := m.pool.Exec(ctx,
+		"INSERT INTO public.tenants (id, schema_name) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING", id, schema); err != nil {
+		return Report{Tenant: id}, fmt.Errorf("ошибка записи арендатора: %w", err)
+	}
+
+	r := m.migrate(ctx, id, schema, true)
+	return r, r.Err
+}
+
+// Tenants — арендаторы из реестра
+func (m *Migrator) Tenants(ctx context.Context) ([]string, error) {
+	if _, err := m.pool.Exec(ctx, registryDDL); err != nil {
+		return nil, fmt.Errorf("ошибка создания реестра арендаторов: %w", err)
+	}
+	rows, err := m.pool.Query(ctx, "SELECT id FROM public.tenants ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения реестра арендаторов: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MigrateAll обновляет схемы всех арендаторов по очереди. Ошибка одного не останавливает остальных:
+// она остаётся в его Report. Ошибка вернётся, только если не удалось прочитать реестр.
+func (m *Migrator) MigrateAll(ctx context.Context) ([]Report, error) {
+	return m.each(ctx, true)
+}
+
+// Status — версии схем арендаторов без изменений
+func (m *Migrator) Status(ctx context.Context) ([]Report, error) {
+	return m.each(ctx, false)
+}
+
+func (m *Migrator) each(ctx context.Context, up bool) ([]Report, error) {
+	ids, err := m.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(ids))
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return reports, ctx.Err()
+		}
+		schema, err := Schema(id)
+		if err != nil {
+			reports = append(reports, Report{Tenant: id, Err: err})
+			continue
+		}
+		reports = append(reports, m.migrate(ctx, id, schema, up))
+	}
+	return reports, nil
+}
+
+// migrate открывает отдельный *sql.DB с search_path арендатора: goose работает через database/sql,
+// и все его запросы, включая таблицу версий, попадают в схему арендатора
+func (m *Migrator) migrate(ctx context.Context, id, schema string, up bool) (r Report) {
+	r = Report{Tenant: id, Schema: schema}
+	start := time.Now()
+	defer func() { r.Took = time.Since(start) }()
+
+	cfg := m.pool.Config().ConnConfig.Copy()
+	cfg.RuntimeParams["search_path"] = searchPath(schema)
+	db := stdlib.OpenDB(*cfg)
+	defer db.Close()
+
+	p, err := goose.NewProvider(goose.DialectPostgres, db, m.fsys)
+	if err != nil {
+		r.Err = fmt.Errorf("ошибка чтения миграций: %w", err)
+		return r
+	}
+	if r.Before, r.Target, err = p.GetVersions(ctx); err != nil {
+		r.Err = fmt.Errorf("ошибка чтения версии: %w", err)
+		return r
+	}
+	r.After = r.Before
+	if !up {
+		return r
+	}
+	if err := m.ensureExtensions(ctx); err != nil {
+		r.Err = err
+		return r
+	}
+
+	results, err := p.Up(ctx)
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		// Часть миграций успела примениться до ошибки
+		results = partial.Applied
+		err = fmt.Errorf("миграция %d: %w", partial.Failed.Source.Version, partial.Err)
+	}
+	for _, res := range results {
+		r.Applied = append(r.Applied, res.Source.Version)
+	}
+	if err != nil {
+		r.Err = fmt.Errorf("ошибка миграции: %w", err)
+	}
+	if v, verr := p.GetDBVersion(ctx); verr == nil {
+		r.After = v
+	}
+	return r
+}
+
+// ensureExtensions создаёт ExtensionsSchema и расширения в ней. Уже установленное в другой схеме
+// расширение не переносится: ALTER EXTENSION ... SET SCHEMA extensions выполняет администратор
+func (m *Migrator) ensureExtensions(ctx context.Context) error {
+	schema := ident.Quote(ident.Postgres, ExtensionsSchema)
+	//sqlvet:ignore имя схемы — константа пакета, экранировано ident.Quote
+	if _, err := m.pool.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema); err != nil {
+		return fmt.Errorf("ошибка создания схемы %s: %w", ExtensionsSchema, err)
+	}
+	for _, ext := range extensions {
+		//sqlvet:ignore имена расширений и схемы — константы пакета, экранированы ident.Quote
+		if _, err := m.pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+ident.Quote(ident.Postgres, ext)+" SCHEMA "+schema); err != nil {
+			return fmt.Errorf("ошибка установки расширения %s: %w", ext, err)
+		}
+	}
+	return nil
+}
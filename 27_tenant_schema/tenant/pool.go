@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+// Configure добавляет в конфигурацию пула переключение search_path:
+// BeforeAcquire ставит схему арендатора из контекста, AfterRelease возвращает search_path соединения.
+// По умолчанию search_path соединения — только ExtensionsSchema: без арендатора в контексте
+// таблиц не видно ни в схемах арендаторов, ни в public, и забытый WithTenant заканчивается
+// ошибкой "relation does not exist", а не чужими данными.
+//
+// Уже заданные в cfg хуки вызываются после переключения.
+func Configure(cfg *pgxpool.Config) {
+	if cfg.ConnConfig.RuntimeParams == nil {
+		cfg.ConnConfig.RuntimeParams = make(map[string]string)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = ident.Quote(ident.Postgres, ExtensionsSchema)
+
+	// Схема, выставленная на соединении; по ней AfterRelease понимает, нужно ли сбрасывать
+	var schemas sync.Map // *pgx.Conn -> string
+
+	before, after, beforeClose := cfg.BeforeAcquire, cfg.AfterRelease, cfg.BeforeClose
+
+	cfg.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		schema, ok := schemaFromContext(ctx)
+		if !ok {
+			return before == nil || before(ctx, conn)
+		}
+		if _, err := conn.Exec(ctx, "SELECT set_config('search_path', $1, false)", searchPath(schema)); err != nil {
+			// false — пул закроет соединение и возьмёт другое
+			return false
+		}
+		schemas.Store(conn, schema)
+		return before == nil || before(ctx, conn)
+	}
+
+	cfg.AfterRelease = func(conn *pgx.Conn) bool {
+		if _, ok := schemas.LoadAndDelete(conn); ok {
+			if _, err := conn.Exec(context.Background(), "RESET search_path"); err != nil {
+				return false
+			}
+		}
+		return after == nil || after(conn)
+	}
+
+	cfg.BeforeClose = func(conn *pgx.Conn) {
+		schemas.Delete(conn)
+		if beforeClose != nil {
+			beforeClose(conn)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package tenant — схема на арендатора (schema-per-tenant) в PostgreSQL.
+//
+// У каждого арендатора своя схема tenant_<id> с полным набором таблиц из миграций goose.
+// Запросы остаются без имени схемы: при получении соединения из пула ставится search_path
+// арендатора из контекста, при возврате — сбрасывается.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/akozadaev/go_db_20/21_ident/ident"
+)
+
+const schemaPrefix = "tenant_"
+
+var (
+	ErrInvalidID = errors.New("недопустимый идентификатор арендатора")
+	ErrNoTenant  = errors.New("арендатор не задан в контексте")
+)
+
+// idRe — идентификатор попадает в имя схемы, поэтому только строчные латинские буквы, цифры и _
+var idRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Schema — имя схемы арендатора (без кавычек)
+func Schema(id string) (string, error) {
+	if !idRe.MatchString(id) {
+		return "", fmt.Errorf("%w: %q (допустимо: a-z, 0-9, _, начиная с буквы)", ErrInvalidID, id)
+	}
+	return schemaPrefix + id, nil
+}
+
+// ExtensionsSchema — схема для расширений (pgcrypto из первой миграции). Она стоит в search_path
+// после схемы арендатора: функции расширений доступны, а таблиц в ней нет
+const ExtensionsSchema = "extensions"
+
+// searchPath — схема арендатора и схема расширений. public в search_path нет: таблица,
+// которой нет у арендатора (например, после неудачной миграции), не найдётся в public
+func searchPath(schema string) string {
+	return ident.Quote(ident.Postgres, schema) + ", " + ident.Quote(ident.Postgres, ExtensionsSchema)
+}
+
+type tenantKey struct{}
+
+type tenantValue struct {
+	id, schema string
+}
+
+// WithTenant — контекст запросов арендатора. id проверяется здесь:
+// из BeforeAcquire ошибку вернуть нельзя, поэтому в контекст попадают только допустимые.
+func WithTenant(ctx context.Context, id string) (context.Context, error) {
+	schema, err := Schema(id)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, tenantKey{}, tenantValue{id: id, schema: schema}), nil
+}
+
+// FromContext — арендатор из контекста
+func FromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(tenantKey{}).(tenantValue)
+	return v.id, ok
+}
+
+func schemaFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(tenantKey{}).(tenantValue)
+	return v.schema, ok
+}
@@ -0,0 +1,23 @@
+module github.com/akozadaev/go_db_20/28_tenant_rls
+
+go 1.25.1
+
+require (
+	github.com/akozadaev/go_db_20/11_pgx_conn_pool_migration_goose v0.0.0
+	github.com/jackc/pgx/v5 v5.7.6
+	github.com/pressly/goose/v3 v3.26.0
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/mfridman/interpolate v0.0.2 // indirect
+	github.com/sethvargo/go-retry v0.3.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/crypto v0.40.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
+)
+
+replace github.com/akozadaev/go_db_20/11_pgx_conn_pool_migration_goose => ../11_pgx_conn_pool_migration_goose
@@ -0,0 +1,131 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL CHECK (name <> '')
+);
+
+-- Существующие данные принадлежат арендатору по умолчанию
+INSERT INTO tenants (name) VALUES ('default') ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE accounts ADD COLUMN tenant_id INTEGER REFERENCES tenants(id);
+UPDATE accounts SET tenant_id = (SELECT id FROM tenants WHERE name = 'default');
+
+ALTER TABLE sessions ADD COLUMN tenant_id INTEGER REFERENCES tenants(id);
+UPDATE sessions s SET tenant_id = a.tenant_id FROM accounts a WHERE a.id = s.account_id;
+
+ALTER TABLE audit_logs ADD COLUMN tenant_id INTEGER REFERENCES tenants(id);
+UPDATE audit_logs l SET tenant_id = COALESCE(
+    (SELECT a.tenant_id FROM accounts a WHERE a.id = l.account_id),
+    (SELECT id FROM tenants WHERE name = 'default')
+);
+
+-- Новые строки получают арендатора из настройки транзакции: INSERT без tenant_id попадает к своему
+ALTER TABLE accounts ALTER COLUMN tenant_id SET NOT NULL,
+    ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::integer;
+ALTER TABLE sessions ALTER COLUMN tenant_id SET NOT NULL,
+    ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::integer;
+ALTER TABLE audit_logs ALTER COLUMN tenant_id SET NOT NULL,
+    ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::integer;
+
+-- Уникальность в пределах арендатора: глобальная выдавала бы чужие email через ошибку 23505
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_username_key;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_email_key;
+ALTER TABLE accounts ADD CONSTRAINT accounts_tenant_username_key UNIQUE (tenant_id, username);
+ALTER TABLE accounts ADD CONSTRAINT accounts_tenant_email_key UNIQUE (tenant_id, email);
+
+-- Сессия не может ссылаться на аккаунт другого арендатора: проверка внешнего ключа RLS не видит
+ALTER TABLE accounts ADD CONSTRAINT accounts_id_tenant_key UNIQUE (id, tenant_id);
+ALTER TABLE sessions ADD CONSTRAINT sessions_account_tenant_fkey
+    FOREIGN KEY (account_id, tenant_id) REFERENCES accounts (id, tenant_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_tenant_id ON sessions (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_id ON audit_logs (tenant_id);
+
+-- FORCE — политики действуют и на владельца таблиц
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sessions FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_logs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_logs FORCE ROW LEVEL SECURITY;
+ALTER TABLE account_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE account_roles FORCE ROW LEVEL SECURITY;
+
+-- Без app.tenant_id условие NULL: ни одной строки, ни одной вставки
+CREATE POLICY tenant_isolation ON accounts
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
+CREATE POLICY tenant_isolation ON sessions
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
+CREATE POLICY tenant_isolation ON audit_logs
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
+-- У account_roles нет tenant_id: строка видна, если виден её аккаунт (подзапрос к accounts проходит через политику accounts)
+CREATE POLICY tenant_isolation ON account_roles
+    USING (EXISTS (SELECT 1 FROM accounts a WHERE a.id = account_id))
+    WITH CHECK (EXISTS (SELECT 1 FROM accounts a WHERE a.id = account_id));
+
+-- Изоляция аккаунта: с app.account_id видны только свои сессии и записи аудита.
+-- RESTRICTIVE — в дополнение к tenant_isolation, а не вместо неё
+CREATE POLICY account_isolation ON sessions AS RESTRICTIVE
+    USING (NULLIF(current_setting('app.account_id', true), '') IS NULL
+        OR account_id = NULLIF(current_setting('app.account_id', true), '')::integer);
+CREATE POLICY account_isolation ON audit_logs AS RESTRICTIVE
+    USING (NULLIF(current_setting('app.account_id', true), '') IS NULL
+        OR account_id = NULLIF(current_setting('app.account_id', true), '')::integer);
+
+-- Суперпользователь и роли с BYPASSRLS политики не видят. Приложение переключается на эту роль
+-- в каждой транзакции (SET LOCAL ROLE), поэтому политики действуют при любом пользователе подключения
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'app_tenant') THEN
+        CREATE ROLE app_tenant NOLOGIN;
+    END IF;
+END
+$$;
+GRANT app_tenant TO CURRENT_USER;
+GRANT SELECT ON tenants TO app_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON accounts, sessions, audit_logs, account_roles TO app_tenant;
+-- Справочники ролей и прав общие для всех арендаторов: изменять их может только владелец
+GRANT SELECT ON roles, permissions, role_permissions TO app_tenant;
+GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO app_tenant;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Роль общая для всего кластера и может использоваться в других базах — только отзываем права
+REVOKE ALL ON tenants, accounts, sessions, audit_logs, roles, account_roles, permissions, role_permissions FROM app_tenant;
+REVOKE USAGE ON ALL SEQUENCES IN SCHEMA public FROM app_tenant;
+
+DROP POLICY IF EXISTS account_isolation ON audit_logs;
+DROP POLICY IF EXISTS account_isolation ON sessions;
+DROP POLICY IF EXISTS tenant_isolation ON account_roles;
+DROP POLICY IF EXISTS tenant_isolation ON audit_logs;
+DROP POLICY IF EXISTS tenant_isolation ON sessions;
+DROP POLICY IF EXISTS tenant_isolation ON accounts;
+ALTER TABLE account_roles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE account_roles DISABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_logs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_logs DISABLE ROW LEVEL SECURITY;
+ALTER TABLE sessions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE sessions DISABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_audit_logs_tenant_id;
+DROP INDEX IF EXISTS idx_sessions_tenant_id;
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_account_tenant_fkey;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_id_tenant_key;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_tenant_email_key;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_tenant_username_key;
+-- Не выполнится, если у разных арендаторов есть одинаковые username или email
+ALTER TABLE accounts ADD CONSTRAINT accounts_username_key UNIQUE (username);
+ALTER TABLE accounts ADD CONSTRAINT accounts_email_key UNIQUE (email);
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
+-- +goose StatementEnd
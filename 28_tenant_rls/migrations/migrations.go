@@ -0,0 +1,15 @@
+// Package migrations — схема аккаунтов из 11_pgx_conn_pool_migration_goose
+// и политики RLS урока поверх неё.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/akozadaev/go_db_20/11_pgx_conn_pool_migration_goose/migrations"
+)
+
+//go:embed *.sql
+var own embed.FS
+
+// FS — общие миграции 001–003 и 004_tenant_rls.sql в одном каталоге для goose.
+var FS = migrations.With(own)
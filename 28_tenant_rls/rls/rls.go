@@ -0,0 +1,92 @@
+// Package rls — изоляция арендаторов через row-level security PostgreSQL.
+//
+// Политики из миграции 004_tenant_rls.sql сравнивают tenant_id строки с настройкой app.tenant_id.
+// InTx выставляет её в начале каждой транзакции только на время транзакции (как SET LOCAL),
+// поэтому соединение возвращается в пул без арендатора. Транзакция переключается на роль без BYPASSRLS:
+// политики действуют, даже если приложение подключается суперпользователем.
+package rls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultRole — роль из миграции, на которую переключается транзакция
+const DefaultRole = "app_tenant"
+
+var ErrNoTenant = errors.New("арендатор не задан в контексте")
+
+type scopeKey struct{}
+
+// Scope — чьи данные видит транзакция
+type Scope struct {
+	TenantID int
+	// AccountID — 0: все аккаунты арендатора; иначе только сессии и аудит этого аккаунта
+	AccountID int
+}
+
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeKey{}).(Scope)
+	return s, ok && s.TenantID > 0
+}
+
+// DB — пул, который выполняет запросы только в транзакциях арендатора
+type DB struct {
+	pool *pgxpool.Pool
+	role string
+}
+
+// New — role: роль без BYPASSRLS, на которую переключается каждая транзакция
+// (суперпользователь и владелец с BYPASSRLS политики не видят); "" — DefaultRole
+func New(pool *pgxpool.Pool, role string) *DB {
+	if role == "" {
+		role = DefaultRole
+	}
+	return &DB{pool: pool, role: role}
+}
+
+// InTx выполняет fn в транзакции арендатора из контекста: Commit при nil, иначе Rollback.
+// Без арендатора в контексте транзакция не начинается — ErrNoTenant.
+func (d *DB) InTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	return d.InTxOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+func (d *DB) InTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	scope, ok := ScopeFrom(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return pgx.BeginTxFunc(ctx, d.pool, opts, func(tx pgx.Tx) error {
+		if err := d.setScope(ctx, tx, scope); err != nil {
+			return err
+		}
+		return fn(ctx, tx)
+	})
+}
+
+// setScope — аналог SET LOCAL: SET не принимает параметры, а set_config(..., true)
+// принимает и так же действует до конца транзакции
+func (d *DB) setScope(ctx context.Context, tx pgx.Tx, s Scope) error {
+	//sqlvet:ignore имя роли из конфигурации, экранировано pgx.Identifier
+	if _, err := tx.Exec(ctx, "SET LOCAL ROLE "+pgx.Identifier{d.role}.Sanitize()); err != nil {
+		return fmt.Errorf("ошибка переключения на роль %s: %w", d.role, err)
+	}
+	account := ""
+	if s.AccountID > 0 {
+		account = strconv.Itoa(s.AccountID)
+	}
+	if _, err := tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true), set_config('app.account_id', $2, true)",
+		strconv.Itoa(s.TenantID), account); err != nil {
+		return fmt.Errorf("ошибка установки арендатора: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Algorithm string
+
+const (
+	Argon2id Algorithm = "argon2id"
+	Bcrypt   Algorithm = "bcrypt"
+)
+
+// Params — алгоритм и его параметры. Поля другого алгоритма не используются.
+type Params struct {
+	Algorithm Algorithm
+
+	// argon2id: Memory в КиБ
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+
+	// bcrypt
+	Cost int
+}
+
+// DefaultParams — argon2id с минимальными параметрами из рекомендаций OWASP (19 МиБ, 2 прохода)
+var DefaultParams = Params{Algorithm: Argon2id, Time: 2, Memory: 19 * 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+
+// BcryptParams — bcrypt для окружений, где argon2id недоступен или память ограничена
+var BcryptParams = Params{Algorithm: Bcrypt, Cost: 12}
+
+var ErrUnknownHash = errors.New("неизвестный формат хеша пароля")
+
+// Hash возвращает хеш в формате, где вместе с ним записаны алгоритм и параметры:
+// $argon2id$v=19$m=19456,t=2,p=1$<соль>$<хеш> или $2a$12$... для bcrypt
+func Hash(password string, p Params) (string, error) {
+	switch p.Algorithm {
+	case Argon2id:
+		salt := make([]byte, p.SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case Bcrypt:
+		h, err := bcrypt.GenerateFromPassword([]byte(password), p.Cost)
+		if err != nil {
+			return "", err
+		}
+		return string(h), nil
+	}
+	return "", fmt.Errorf("неизвестный алгоритм %q", p.Algorithm)
+}
+
+// Verify сравнивает пароль с хешем за время, не зависящее от того, где расходятся байты
+func Verify(password, encoded string) (bool, error) {
+	if strings.HasPrefix(encoded, "$2") {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	p, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+// NeedsRehash — хеш сделан другим алгоритмом или с другими параметрами.
+// Проверяется после успешного входа, когда пароль известен и его можно перехешировать.
+func NeedsRehash(encoded string, want Params) bool {
+	got, err := ParamsOf(encoded)
+	if err != nil || got.Algorithm != want.Algorithm {
+		return true
+	}
+	if got.Algorithm == Bcrypt {
+		return got.Cost != want.Cost
+	}
+	return got.Time != want.Time || got.Memory != want.Memory || got.Threads != want.Threads ||
+		got.KeyLen != want.KeyLen || got.SaltLen != want.SaltLen
+}
+
+// ParamsOf — параметры, с которыми сделан хеш
+func ParamsOf(encoded string) (Params, error) {
+	if strings.HasPrefix(encoded, "$2") {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return Params{}, fmt.Errorf("%w: %w", ErrUnknownHash, err)
+		}
+		return Params{Algorithm: Bcrypt, Cost: cost}, nil
+	}
+	p, _, _, err := decodeArgon2(encoded)
+	return p, err
+}
+
+func decodeArgon2(encoded string) (p Params, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != string(Argon2id) {
+		return p, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("%w: версия argon2 %q", ErrUnknownHash, parts[2])
+	}
+	p.Algorithm = Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: параметры %q", ErrUnknownHash, parts[3])
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: соль: %w", ErrUnknownHash, err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: хеш: %w", ErrUnknownHash, err)
+	}
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,316 @@
+// Package auth — пароли аккаунтов, вход с блокировкой после ошибок и сессии по токену.
+//
+// Пароли хешируются argon2id или bcrypt (Params), хеш хранится в credentials вместе с параметрами.
+// Если параметры изменились, хеш пересчитывается при следующем успешном входе.
+// Вход, выход, неудачные попытки и блокировки пишутся в audit_logs.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrInvalidCredentials — общий ответ на неизвестный логин, неверный пароль, отключённый
+	// и (без Options.RevealLock) заблокированный аккаунт: по нему нельзя узнать, существует ли аккаунт
+	ErrInvalidCredentials = errors.New("неверный логин или пароль")
+	// ErrLocked — только при Options.RevealLock
+	ErrLocked          = errors.New("аккаунт временно заблокирован")
+	ErrWeakPassword    = errors.New("пароль не подходит")
+	ErrSessionNotFound = errors.New("сессия не найдена или истекла")
+)
+
+const (
+	minPasswordLen = 8
+	// bcrypt учитывает только первые 72 байта, argon2id ограничиваем разумным размером
+	maxBcryptBytes   = 72
+	maxPasswordBytes = 1024
+)
+
+type Options struct {
+	// Params — текущие параметры хеширования; пустые — DefaultParams
+	Params Params
+	// MaxFailures — неудачных попыток подряд до блокировки; 0 — 5
+	MaxFailures int
+	// LockFor — длительность блокировки; 0 — 15 минут
+	LockFor time.Duration
+	// SessionTTL — срок жизни сессии; 0 — 24 часа
+	SessionTTL time.Duration
+	// RevealLock — сообщать о блокировке (ErrLocked). По умолчанию заблокированный аккаунт получает
+	// ErrInvalidCredentials: отдельная ошибка выдала бы, что аккаунт существует. Блокировка видна в аудите.
+	RevealLock bool
+}
+
+// Client — откуда пришёл запрос, для записи в аудит
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Session — сессия; Token есть только в ответе Login, в базе хранится его хеш
+type Session struct {
+	ID        string
+	AccountID int
+	Token     string
+	ExpiresAt time.Time
+}
+
+type Service struct {
+	pool *pgxpool.Pool
+	opts Options
+	// dummyHash проверяется для неизвестного логина: время ответа такое же, как для существующего
+	dummyHash string
+}
+
+func NewService(pool *pgxpool.Pool, opts Options) (*Service, error) {
+	if opts.Params.Algorithm == "" {
+		opts.Params = DefaultParams
+	}
+	if opts.MaxFailures == 0 {
+		opts.MaxFailures = 5
+	}
+	if opts.LockFor == 0 {
+		opts.LockFor = 15 * time.Minute
+	}
+	if opts.SessionTTL == 0 {
+		opts.SessionTTL = 24 * time.Hour
+	}
+	dummy, err := Hash("dummy password for timing", opts.Params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка параметров хеширования: %w", err)
+	}
+	return &Service{pool: pool, opts: opts, dummyHash: dummy}, nil
+}
+
+func (s *Service) validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLen {
+		return fmt.Errorf("%w: минимум %d символов", ErrWeakPassword, minPasswordLen)
+	}
+	limit := maxPasswordBytes
+	if s.opts.Params.Algorithm == Bcrypt {
+		limit = maxBcryptBytes
+	}
+	if len(password) > limit {
+		return fmt.Errorf("%w: максимум %d байт", ErrWeakPassword, limit)
+	}
+	return nil
+}
+
+// SetPassword задаёт пароль, снимает блокировку и завершает все сессии аккаунта
+func (s *Service) SetPassword(ctx context.Context, accountID int, password string) error {
+	if err := s.validatePassword(password); err != nil {
+		return err
+	}
+	hash, err := Hash(password, s.opts.Params)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования: %w", err)
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO credentials (account_id, password_hash) VALUES ($1, $2)
+			ON CONFLICT (account_id) DO UPDATE SET password_hash = EXCLUDED.password_hash,
+				failed_attempts = 0, locked_until = NULL, password_changed_at = NOW()`,
+			accountID, hash); err != nil {
+			return fmt.Errorf("ошибка сохранения пароля: %w", err)
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM sessions WHERE account_id = $1", accountID); err != nil {
+			return fmt.Errorf("ошибка завершения сессий: %w", err)
+		}
+		return audit(ctx, tx, &accountID, "password_changed", nil, Client{})
+	})
+}
+
+// Login проверяет пароль и создаёт сессию. Строка credentials блокируется на время проверки:
+// параллельные попытки считаются по очереди и не обходят лимит.
+// Неудачная попытка фиксируется в базе, хотя Login и возвращает ошибку.
+func (s *Service) Login(ctx context.Context, login, password string, c Client) (Session, error) {
+	var (
+		sess     Session
+		loginErr error
+	)
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var (
+			accountID   int
+			active      bool
+			hash        string
+			failed      int
+			locked      bool
+			lockedUntil *time.Time
+		)
+		err := tx.QueryRow(ctx, `
+			SELECT a.id, a.is_active, c.password_hash, c.failed_attempts,
+				COALESCE(c.locked_until > NOW(), false), c.locked_until
+			FROM accounts a
+			JOIN credentials c ON c.account_id = a.id
+			WHERE a.username = $1 OR a.email = $1
+			-- username одного аккаунта может совпасть с email другого: приоритет у username
+			ORDER BY (a.username = $1) DESC
+			LIMIT 1
+			FOR UPDATE OF c`, login).Scan(&accountID, &active, &hash, &failed, &locked, &lockedUntil)
+		if errors.Is(err, pgx.ErrNoRows) {
+			_, _ = Verify(password, s.dummyHash)
+			loginErr = ErrInvalidCredentials
+			return audit(ctx, tx, nil, "login_failed", map[string]any{"reason": "unknown_login", "login": login}, c)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка поиска аккаунта: %w", err)
+		}
+
+		// Пароль проверяется на всех путях: время ответа не зависит от того, есть ли аккаунт и заблокирован ли он
+		ok, err := Verify(password, hash)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки пароля: %w", err)
+		}
+		if locked {
+			loginErr = ErrInvalidCredentials
+			if s.opts.RevealLock {
+				loginErr = fmt.Errorf("%w до %s", ErrLocked, lockedUntil.Format(time.DateTime))
+			}
+			return audit(ctx, tx, &accountID, "login_failed", map[string]any{"reason": "locked"}, c)
+		}
+		if !active {
+			loginErr = ErrInvalidCredentials
+			return audit(ctx, tx, &accountID, "login_failed", map[string]any{"reason": "inactive"}, c)
+		}
+		if !ok {
+			loginErr = ErrInvalidCredentials
+			return s.recordFailure(ctx, tx, accountID, failed+1, c)
+		}
+
+		rehashed := false
+		if NeedsRehash(hash, s.opts.Params) {
+			if hash, err = Hash(password, s.opts.Params); err != nil {
+				return fmt.Errorf("ошибка хеширования: %w", err)
+			}
+			rehashed = true
+		}
+		if _, err := tx.Exec(ctx,
+			"UPDATE credentials SET failed_attempts = 0, locked_until = NULL, password_hash = $2 WHERE account_id = $1",
+			accountID, hash); err != nil {
+			return fmt.Errorf("ошибка обновления учётных данных: %w", err)
+		}
+
+		if sess, err = s.createSession(ctx, tx, accountID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, &accountID, "login", map[string]any{"session_id": sess.ID, "rehashed": rehashed}, c)
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	return sess, loginErr
+}
+
+// recordFailure увеличивает счётчик ошибок; на MaxFailures блокирует аккаунт и сбрасывает счётчик
+func (s *Service) recordFailure(ctx context.Context, tx pgx.Tx, accountID, failed int, c Client) error {
+	if failed < s.opts.MaxFailures {
+		if _, err := tx.Exec(ctx, "UPDATE credentials SET failed_attempts = $2 WHERE account_id = $1", accountID, failed); err != nil {
+			return fmt.Errorf("ошибка учёта попытки: %w", err)
+		}
+		return audit(ctx, tx, &accountID, "login_failed", map[string]any{"reason": "wrong_password", "failed_attempts": failed}, c)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE credentials SET failed_attempts = 0, locked_until = NOW() + make_interval(secs => $2)
+		WHERE account_id = $1`, accountID, s.opts.LockFor.Seconds()); err != nil {
+		return fmt.Errorf("ошибка блокировки: %w", err)
+	}
+	if err := audit(ctx, tx, &accountID, "login_failed", map[string]any{"reason": "wrong_password", "failed_attempts": failed}, c); err != nil {
+		return err
+	}
+	return audit(ctx, tx, &accountID, "account_locked", map[string]any{"lock_seconds": s.opts.LockFor.Seconds()}, c)
+}
+
+// Unlock снимает блокировку досрочно
+func (s *Service) Unlock(ctx context.Context, accountID int) error {
+	_, err := s.pool.Exec(ctx, "UPDATE credentials SET failed_attempts = 0, locked_until = NULL WHERE account_id = $1", accountID)
+	return err
+}
+
+// createSession — случайный токен отдаётся клиенту, в sessions хранится только его SHA-256:
+// утечка таблицы не даёт войти под чужой сессией
+func (s *Service) createSession(ctx context.Context, tx pgx.Tx, accountID int) (Session, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Session{}, err
+	}
+	sess := Session{AccountID: accountID, Token: base64.RawURLEncoding.EncodeToString(raw)}
+	err := tx.QueryRow(ctx, `
+		INSERT INTO sessions (account_id, token_hash, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		RETURNING id, expires_at`, accountID, tokenHash(sess.Token), s.opts.SessionTTL.Seconds()).Scan(&sess.ID, &sess.ExpiresAt)
+	if err != nil {
+		return Session{}, fmt.Errorf("ошибка создания сессии: %w", err)
+	}
+	return sess, nil
+}
+
+// Authenticate находит действующую сессию по токену из заголовка Authorization
+func (s *Service) Authenticate(ctx context.Context, token string) (Session, error) {
+	sess := Session{Token: token}
+	err := s.pool.QueryRow(ctx, `
+		SELECT s.id, s.account_id, s.expires_at
+		FROM sessions s
+		JOIN accounts a ON a.id = s.account_id
+		WHERE s.token_hash = $1 AND s.expires_at > NOW() AND a.is_active`, tokenHash(token)).
+		Scan(&sess.ID, &sess.AccountID, &sess.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return Session{}, fmt.Errorf("ошибка поиска сессии: %w", err)
+	}
+	return sess, nil
+}
+
+// Logout удаляет сессию по токену
+func (s *Service) Logout(ctx context.Context, token string, c Client) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var (
+			id        string
+			accountID int
+		)
+		err := tx.QueryRow(ctx, "DELETE FROM sessions WHERE token_hash = $1 RETURNING id, account_id", tokenHash(token)).
+			Scan(&id, &accountID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка удаления сессии: %w", err)
+		}
+		return audit(ctx, tx, &accountID, "logout", map[string]any{"session_id": id}, c)
+	})
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func audit(ctx context.Context, tx pgx.Tx, accountID *int, action string, details map[string]any, c Client) error {
+	if details == nil {
+		details = make(map[string]any)
+	}
+	if c.IP != "" {
+		details["ip"] = c.IP
+	}
+	if c.UserAgent != "" {
+		details["user_agent"] = c.UserAgent
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO audit_logs (account_id, action, details) VALUES ($1, $2, $3)",
+		accountID, action, details); err != nil {
+		return fmt.Errorf("ошибка записи в аудит: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS credentials (
+    account_id INTEGER PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    -- Строка в формате PHC ($argon2id$...) или bcrypt ($2a$...): алгоритм и параметры хранятся вместе с хешем
+    password_hash TEXT NOT NULL CHECK (password_hash LIKE '$%'),
+    failed_attempts INTEGER NOT NULL DEFAULT 0 CHECK (failed_attempts >= 0),
+    locked_until TIMESTAMP,
+    password_changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Поиск сессии по токену при каждом запросе
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions (token_hash);
+
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check CHECK (action IN (
+    'login', 'logout', 'update_profile', 'delete_account',
+    'login_failed', 'account_locked', 'password_changed'
+));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM audit_logs WHERE action IN ('login_failed', 'account_locked', 'password_changed');
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check
+    CHECK (action IN ('login', 'logout', 'update_profile', 'delete_account'));
+
+DROP INDEX IF EXISTS idx_sessions_token_hash;
+DROP TABLE IF EXISTS credentials;
+-- +goose StatementEnd
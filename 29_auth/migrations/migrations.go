@@ -0,0 +1,15 @@
+// Package migrations — схема аккаунтов из 11_pgx_conn_pool_migration_goose и учётные данные.
+// Файлы встроены в бинарник, чтобы их могли применять и другие уроки (REST API).
+package migrations
+
+import (
+	"embed"
+
+	"github.com/akozadaev/go_db_20/11_pgx_conn_pool_migration_goose/migrations"
+)
+
+//go:embed *.sql
+var own embed.FS
+
+// FS — общие миграции 001–003 и миграция урока 004 в одном каталоге для goose.
+var FS = migrations.With(own)
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akozadaev/go_db_20/10_pgx_conn_pool_migration/account"
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+	"github.com/akozadaev/go_db_20/13_query_builder/qb"
+	"github.com/akozadaev/go_db_20/14_pagination/paginate"
+)
+
+// Account — аккаунт в запросах и ответах
+type Account struct {
+	ID        int64     `db:"id" json:"id"`
+	Username  string    `db:"username" json:"username"`
+	Email     string    `db:"email" json:"email"`
+	IsActive  bool      `db:"is_active" json:"is_active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Validate — общие правила из 10_pgx_conn_pool_migration/account
+func (a *Account) Validate() error {
+	return (&account.Account{Username: a.Username, Email: a.Email}).Validate()
+}
+
+var accountsKeyset = paginate.Keyset[Account]{
+	CreatedAt: "a.created_at",
+	ID:        "a.id",
+	Key:       func(a Account) paginate.Key { return paginate.Key{CreatedAt: a.CreatedAt, ID: a.ID} },
+}
+
+const accountColumns = "id, username, email, is_active, created_at"
+
+// listAccounts — GET /accounts?cursor=&limit=&active=
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) error {
+	req, err := pageRequest(r)
+	if err != nil {
+		return err
+	}
+	base := qb.Select("a.id", "a.username", "a.email", "a.is_active", "a.created_at").From("accounts a")
+	switch r.URL.Query().Get("active") {
+	case "":
+	case "true":
+		base.Where(qb.Eq("a.is_active", true))
+	case "false":
+		base.Where(qb.Eq("a.is_active", false))
+	default:
+		return errorf(http.StatusBadRequest, "active: ожидается true или false")
+	}
+
+	p, err := accountsKeyset.Fetch(r.Context(), s.src, base, req)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newPage(p))
+}
+
+// createAccount — POST /accounts: аккаунт с ролью user, как в 11_pgx_conn_pool_migration_goose
+func (s *Server) createAccount(w http.ResponseWriter, r *http.Request) error {
+	var in struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+	if err := decodeJSON(w, r, &in); err != nil {
+		return err
+	}
+	acc := Account{Username: in.Username, Email: in.Email}
+	if err := acc.Validate(); err != nil {
+		return errorf(http.StatusUnprocessableEntity, "%v", err)
+	}
+
+	err := pgx.BeginFunc(r.Context(), s.pool, func(tx pgx.Tx) error {
+		var err error
+		acc, err = queryAccount(r.Context(), tx,
+			"INSERT INTO accounts (username, email) VALUES ($1, $2) RETURNING "+accountColumns, acc.Username, acc.Email)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(r.Context(),
+			"INSERT INTO account_roles (account_id, role_id) SELECT $1, id FROM roles WHERE name = 'user' ON CONFLICT DO NOTHING",
+			acc.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("/accounts/%d", acc.ID))
+	return writeJSON(w, http.StatusCreated, acc)
+}
+
+// getAccount — GET /accounts/{id}
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	acc, err := queryAccount(r.Context(), s.pool, "SELECT "+accountColumns+" FROM accounts WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, acc)
+}
+
+// updateAccount — PATCH /accounts/{id}: меняются только переданные поля, изменения пишутся в аудит.
+// is_active дополнительно требует manage_users.
+func (s *Server) updateAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	var in struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+		IsActive *bool   `json:"is_active"`
+	}
+	if err := decodeJSON(w, r, &in); err != nil {
+		return err
+	}
+	// Включать и отключать аккаунты — управление пользователями, а не правка профиля
+	if in.IsActive != nil && !can(r.Context(), PermManageUsers) {
+		return s.deny(r, PermManageUsers)
+	}
+
+	var acc Account
+	err = pgx.BeginFunc(r.Context(), s.pool, func(tx pgx.Tx) error {
+		var err error
+		acc, err = queryAccount(r.Context(), tx, "SELECT "+accountColumns+" FROM accounts WHERE id = $1 FOR UPDATE", id)
+		if err != nil {
+			return err
+		}
+
+		changed := make(map[string]any)
+		if in.Username != nil && *in.Username != acc.Username {
+			acc.Username, changed["username"] = *in.Username, *in.Username
+		}
+		if in.Email != nil && *in.Email != acc.Email {
+			acc.Email, changed["email"] = *in.Email, *in.Email
+		}
+		if in.IsActive != nil && *in.IsActive != acc.IsActive {
+			acc.IsActive, changed["is_active"] = *in.IsActive, *in.IsActive
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		if err := acc.Validate(); err != nil {
+			return errorf(http.StatusUnprocessableEntity, "%v", err)
+		}
+
+		if _, err := tx.Exec(r.Context(), "UPDATE accounts SET username = $2, email = $3, is_active = $4 WHERE id = $1",
+			acc.ID, acc.Username, acc.Email, acc.IsActive); err != nil {
+			return err
+		}
+		changed["by"] = sessionFrom(r.Context()).AccountID
+		return audit(r.Context(), tx, &acc.ID, "update_profile", changed)
+	})
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, acc)
+}
+
+// deleteAccount — DELETE /accounts/{id}. Запись аудита ссылается на того, кто удалил:
+// ссылка на удалённый аккаунт обнулилась бы (ON DELETE SET NULL). Если администратор удаляет
+// самого себя, ссылаться не на кого — account_id NULL, удалённый аккаунт остаётся в details.
+func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	err = pgx.BeginFunc(r.Context(), s.pool, func(tx pgx.Tx) error {
+		var username string
+		if err := tx.QueryRow(r.Context(), "DELETE FROM accounts WHERE id = $1 RETURNING username", id).Scan(&username); err != nil {
+			return err
+		}
+		by := int64(sessionFrom(r.Context()).AccountID)
+		actor := &by
+		if id == by {
+			actor = nil
+		}
+		return audit(r.Context(), tx, actor, "delete_account", map[string]any{"account_id": id, "username": username})
+	})
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// queryAccount выполняет запрос, возвращающий одну строку accountColumns
+func queryAccount(ctx context.Context, q rowscan.PgxQuerier, query string, args ...any) (Account, error) {
+	return rowscan.QueryOne[Account](ctx, rowscan.Pgx(q), query, args...)
+}
+
+// accountExists отличает «аккаунта нет» (404) от пустого списка
+func (s *Server) accountExists(ctx context.Context, id int64) error {
+	var ok bool
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM accounts WHERE id = $1)", id).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return errorf(http.StatusNotFound, "аккаунт %d не найден", id)
+	}
+	return nil
+}
+
+// execer — пул или транзакция
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// audit пишет запись аудита; accountID nil — запись ни к какому аккаунту не привязана
+func audit(ctx context.Context, db execer, accountID *int64, action string, details map[string]any) error {
+	_, err := db.Exec(ctx, "INSERT INTO audit_logs (account_id, action, details) VALUES ($1, $2, $3)", accountID, action, details)
+	return err
+}
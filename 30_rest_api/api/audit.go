@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akozadaev/go_db_20/13_query_builder/qb"
+	"github.com/akozadaev/go_db_20/14_pagination/paginate"
+)
+
+// AuditLog — запись журнала аудита из миграции 002
+type AuditLog struct {
+	ID        int64           `db:"id" json:"id"`
+	AccountID *int64          `db:"account_id" json:"account_id"`
+	Action    string          `db:"action" json:"action"`
+	Details   json.RawMessage `db:"details" json:"details"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+var auditKeyset = paginate.Keyset[AuditLog]{
+	CreatedAt: "l.created_at",
+	ID:        "l.id",
+	Key:       func(l AuditLog) paginate.Key { return paginate.Key{CreatedAt: l.CreatedAt, ID: l.ID} },
+}
+
+// listAuditLogs — GET /audit-logs?account_id=&action=&cursor=&limit=, новые записи сначала
+func (s *Server) listAuditLogs(w http.ResponseWriter, r *http.Request) error {
+	req, err := pageRequest(r)
+	if err != nil {
+		return err
+	}
+	base := qb.Select("l.id", "l.account_id", "l.action", "l.details", "l.created_at").From("audit_logs l")
+	if v := r.URL.Query().Get("account_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "некорректный account_id")
+		}
+		base.Where(qb.Eq("l.account_id", id))
+	}
+	if v := r.URL.Query().Get("action"); v != "" {
+		base.Where(qb.Eq("l.action", v))
+	}
+
+	p, err := auditKeyset.Fetch(r.Context(), s.src, base, req)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newPage(p))
+}
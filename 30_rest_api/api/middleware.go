@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/akozadaev/go_db_20/29_auth/auth"
+)
+
+type sessionKey struct{}
+
+// authed проверяет токен из Authorization: Bearer и кладёт сессию в контекст запроса
+func (s *Server) authed(h handlerFunc) http.Handler {
+	return s.handle(func(w http.ResponseWriter, r *http.Request) error {
+		token, ok := bearerToken(r)
+		if !ok {
+			return auth.ErrSessionNotFound
+		}
+		sess, err := s.auth.Authenticate(r.Context(), token)
+		if err != nil {
+			return err
+		}
+		return h(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, sess)))
+	})
+}
+
+// sessionFrom — сессия текущего запроса; есть только в обработчиках за authed
+func sessionFrom(ctx context.Context) auth.Session {
+	sess, _ := ctx.Value(sessionKey{}).(auth.Session)
+	return sess
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// client — адрес и User-Agent для аудита. X-Forwarded-For не учитывается:
+// без доверенного прокси перед сервером его может подделать любой клиент.
+func client(r *http.Request) auth.Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return auth.Client{IP: ip, UserAgent: r.UserAgent()}
+}
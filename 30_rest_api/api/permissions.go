@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Права из таблицы permissions (seedData в 09_pgx_conn_pool)
+const (
+	PermRead        = "read"
+	PermWrite       = "write"
+	PermDelete      = "delete"
+	PermManageUsers = "manage_users"
+)
+
+// permSet — права аккаунта, объединённые по всем его ролям
+type permSet map[string]bool
+
+type permsKey struct{}
+
+// require пропускает запрос, только если у аккаунта сессии есть право perm.
+// Права читаются из базы на каждый запрос: отзыв роли действует сразу, без перезахода.
+// Отказ — 403 и запись access_denied в audit_logs.
+func (s *Server) require(perm string, h handlerFunc) http.Handler {
+	return s.authed(func(w http.ResponseWriter, r *http.Request) error {
+		sess := sessionFrom(r.Context())
+		perms, err := s.loadPermissions(r.Context(), sess.AccountID)
+		if err != nil {
+			return err
+		}
+		if !perms[perm] {
+			return s.deny(r, perm)
+		}
+		return h(w, r.WithContext(context.WithValue(r.Context(), permsKey{}, perms)))
+	})
+}
+
+// deny записывает отказ в аудит и возвращает 403
+func (s *Server) deny(r *http.Request, perm string) error {
+	details := map[string]any{
+		"method":   r.Method,
+		"path":     r.URL.Path,
+		"required": perm,
+		"ip":       client(r).IP,
+	}
+	accountID := int64(sessionFrom(r.Context()).AccountID)
+	if err := audit(r.Context(), s.pool, &accountID, "access_denied", details); err != nil {
+		return err
+	}
+	return errorf(http.StatusForbidden, "недостаточно прав: нужно %s", perm)
+}
+
+func (s *Server) loadPermissions(ctx context.Context, accountID int) (permSet, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT p.name
+		FROM account_roles ar
+		JOIN role_permissions rp ON rp.role_id = ar.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ar.account_id = $1`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	perms := make(permSet, len(names))
+	for _, n := range names {
+		perms[n] = true
+	}
+	return perms, nil
+}
+
+// can — есть ли у аккаунта запроса право perm; для проверок внутри обработчиков за require
+func can(ctx context.Context, perm string) bool {
+	perms, _ := ctx.Value(permsKey{}).(permSet)
+	return perms[perm]
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+	"github.com/akozadaev/go_db_20/14_pagination/paginate"
+	"github.com/akozadaev/go_db_20/29_auth/auth"
+)
+
+const maxBodyBytes = 1 << 20
+
+// httpError — ошибка с готовым HTTP-статусом и текстом для клиента
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string { return e.message }
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// handlerFunc — обработчик, возвращающий ошибку; ответ на неё формирует writeError
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (s *Server) handle(h handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			writeError(w, r, err)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// writeError выбирает статус по ошибке. Текст внутренних ошибок клиенту не отдаётся — только в лог.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := http.StatusInternalServerError, "внутренняя ошибка сервера"
+
+	var (
+		he     *httpError
+		pgErr  *pgconn.PgError
+		tooBig *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &he):
+		status, message = he.status, he.message
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, rowscan.ErrNoRows):
+		status, message = http.StatusNotFound, "не найдено"
+	case errors.Is(err, paginate.ErrInvalidCursor):
+		status, message = http.StatusBadRequest, "некорректный курсор"
+	case errors.As(err, &tooBig):
+		status, message = http.StatusRequestEntityTooLarge, "слишком большое тело запроса"
+	case errors.Is(err, auth.ErrSessionNotFound):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		status, message = http.StatusUnauthorized, err.Error()
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		status, message = http.StatusUnauthorized, err.Error()
+	case errors.Is(err, auth.ErrLocked):
+		status, message = http.StatusLocked, err.Error()
+	case errors.Is(err, auth.ErrWeakPassword):
+		status, message = http.StatusUnprocessableEntity, err.Error()
+	case errors.As(err, &pgErr):
+		if st, msg, ok := constraintStatus(pgErr); ok {
+			status, message = st, msg
+		}
+	case errors.Is(err, context.Canceled):
+		// Клиент ушёл — отвечать некому
+		return
+	}
+
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "ошибка обработки запроса", "method", r.Method, "path", r.URL.Path, "err", err)
+	}
+	_ = writeJSON(w, status, map[string]string{"error": message})
+}
+
+// constraintStatus сопоставляет нарушения ограничений статусам; false — ошибка не про данные клиента
+func constraintStatus(pgErr *pgconn.PgError) (int, string, bool) {
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return http.StatusConflict, "запись уже существует: " + pgErr.ConstraintName, true
+	case "23503": // foreign_key_violation
+		return http.StatusUnprocessableEntity, "ссылка на несуществующую запись: " + pgErr.ConstraintName, true
+	case "23514": // check_violation
+		return http.StatusUnprocessableEntity, "нарушено ограничение: " + pgErr.ConstraintName, true
+	case "23502": // not_null_violation
+		return http.StatusUnprocessableEntity, "не заполнено поле: " + pgErr.ColumnName, true
+	case "22001": // string_data_right_truncation
+		return http.StatusUnprocessableEntity, "слишком длинное значение", true
+	}
+	return 0, "", false
+}
+
+// decodeJSON читает тело строго: неизвестные поля и данные после объекта — ошибка 400
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return err
+		}
+		return errorf(http.StatusBadRequest, "некорректный JSON: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errorf(http.StatusBadRequest, "некорректный JSON: лишние данные после объекта")
+	}
+	return nil
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errorf(http.StatusBadRequest, "некорректный идентификатор %s", name)
+	}
+	return id, nil
+}
+
+// pageRequest читает ?cursor=&limit= для курсорной пагинации
+func pageRequest(r *http.Request) (paginate.KeysetRequest, error) {
+	req := paginate.KeysetRequest{Cursor: r.URL.Query().Get("cursor")}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return req, errorf(http.StatusBadRequest, "некорректный limit")
+		}
+		req.Limit = n
+	}
+	return req, nil
+}
+
+// page — страница списка в ответе; курсоры передаются обратно в ?cursor=
+type page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+func newPage[T any](p *paginate.KeysetPage[T]) page[T] {
+	return page[T]{Items: nonNil(p.Items), Next: p.Next, Prev: p.Prev}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+)
+
+type Role struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+type Permission struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+// listAccountRoles — GET /accounts/{id}/roles
+func (s *Server) listAccountRoles(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	if err := s.accountExists(r.Context(), id); err != nil {
+		return err
+	}
+	roles, err := rowscan.QueryAll[Role](r.Context(), s.src.Q, `
+		SELECT r.id, r.name
+		FROM roles r
+		JOIN account_roles ar ON ar.role_id = r.id
+		WHERE ar.account_id = $1
+		ORDER BY r.name`, id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"items": nonNil(roles)})
+}
+
+// assignRole — PUT /accounts/{id}/roles/{role}: роль по имени; повторное назначение не ошибка
+func (s *Server) assignRole(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	if err := s.accountExists(r.Context(), id); err != nil {
+		return err
+	}
+	roleID, err := s.roleID(r, r.PathValue("role"))
+	if err != nil {
+		return err
+	}
+	if _, err := s.pool.Exec(r.Context(),
+		"INSERT INTO account_roles (account_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", id, roleID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// revokeRole — DELETE /accounts/{id}/roles/{role}
+func (s *Server) revokeRole(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	tag, err := s.pool.Exec(r.Context(), `
+		DELETE FROM account_roles ar
+		USING roles r
+		WHERE ar.role_id = r.id AND ar.account_id = $1 AND r.name = $2`, id, r.PathValue("role"))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errorf(http.StatusNotFound, "у аккаунта %d нет роли %s", id, r.PathValue("role"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listRolePermissions — GET /roles/{id}/permissions
+func (s *Server) listRolePermissions(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	var name string
+	if err := s.pool.QueryRow(r.Context(), "SELECT name FROM roles WHERE id = $1", id).Scan(&name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errorf(http.StatusNotFound, "роль %d не найдена", id)
+		}
+		return err
+	}
+	perms, err := rowscan.QueryAll[Permission](r.Context(), s.src.Q, `
+		SELECT p.id, p.name
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+		ORDER BY p.name`, id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"role": Role{ID: id, Name: name}, "items": nonNil(perms)})
+}
+
+func (s *Server) roleID(r *http.Request, name string) (int64, error) {
+	var id int64
+	err := s.pool.QueryRow(r.Context(), "SELECT id FROM roles WHERE name = $1", name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, errorf(http.StatusNotFound, "роль %s не найдена", name)
+	}
+	return id, err
+}
+
+// nonNil — пустой список в JSON как [], а не null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,74 @@
+// Package api — REST API над аккаунтами, ролями, сессиями и журналом аудита (только net/http).
+//
+// Запросы и ответы — JSON. Все маршруты, кроме входа (POST /sessions), требуют заголовок
+// Authorization: Bearer <токен сессии>; токен проверяется по таблице sessions (29_auth).
+// Права на маршруты задаёт таблица routes: без нужного права — 403 и запись access_denied в аудите.
+// Ошибки ограничений PostgreSQL превращаются в 409 (уникальность) и 422 (остальные нарушения).
+package api
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/akozadaev/go_db_20/12_generic_scan/rowscan"
+	"github.com/akozadaev/go_db_20/13_query_builder/qb"
+	"github.com/akozadaev/go_db_20/14_pagination/paginate"
+	"github.com/akozadaev/go_db_20/29_auth/auth"
+)
+
+type Server struct {
+	pool *pgxpool.Pool
+	auth *auth.Service
+	src  paginate.Source
+}
+
+func New(pool *pgxpool.Pool, authSvc *auth.Service) *Server {
+	return &Server{
+		pool: pool,
+		auth: authSvc,
+		src:  paginate.Source{Q: rowscan.Pgx(pool), Dialect: qb.Dollar},
+	}
+}
+
+// route — маршрут и право, без которого он недоступен; пустое perm — достаточно сессии
+type route struct {
+	pattern string
+	perm    string
+	h       handlerFunc
+}
+
+// routes — таблица авторизации: требования видны в одном месте, а не разбросаны по обработчикам
+func (s *Server) routes() []route {
+	return []route{
+		{"GET /sessions", "", s.listSessions},
+		{"DELETE /sessions", "", s.logout},
+
+		{"GET /accounts", PermRead, s.listAccounts},
+		{"POST /accounts", PermWrite, s.createAccount},
+		{"GET /accounts/{id}", PermRead, s.getAccount},
+		{"PATCH /accounts/{id}", PermWrite, s.updateAccount},
+		{"DELETE /accounts/{id}", PermDelete, s.deleteAccount},
+
+		{"GET /accounts/{id}/roles", PermRead, s.listAccountRoles},
+		{"PUT /accounts/{id}/roles/{role}", PermManageUsers, s.assignRole},
+		{"DELETE /accounts/{id}/roles/{role}", PermManageUsers, s.revokeRole},
+		{"GET /roles/{id}/permissions", PermRead, s.listRolePermissions},
+
+		{"GET /audit-logs", PermManageUsers, s.listAuditLogs},
+	}
+}
+
+// Handler возвращает маршрутизатор со всеми эндпоинтами
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /sessions", s.handle(s.login))
+	for _, rt := range s.routes() {
+		if rt.perm == "" {
+			mux.Handle(rt.pattern, s.authed(rt.h))
+			continue
+		}
+		mux.Handle(rt.pattern, s.require(rt.perm, rt.h))
+	}
+	return mux
+}
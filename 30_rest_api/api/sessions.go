@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type loginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type sessionResponse struct {
+	ID        string    `json:"id"`
+	AccountID int       `json:"account_id"`
+	Token     string    `json:"token,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// login — POST /sessions: вход по логину или email; токен возвращается один раз
+func (s *Server) login(w http.ResponseWriter, r *http.Request) error {
+	var req loginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	if req.Login == "" || req.Password == "" {
+		return errorf(http.StatusUnprocessableEntity, "нужны login и password")
+	}
+
+	sess, err := s.auth.Login(r.Context(), req.Login, req.Password, client(r))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, sessionResponse{
+		ID: sess.ID, AccountID: sess.AccountID, Token: sess.Token, ExpiresAt: sess.ExpiresAt,
+	})
+}
+
+type sessionItem struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"`
+}
+
+// listSessions — GET /sessions: действующие сессии текущего аккаунта
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) error {
+	cur := sessionFrom(r.Context())
+	rows, err := s.pool.Query(r.Context(), `
+		SELECT id::text, created_at, expires_at, id = $2::uuid
+		FROM sessions
+		WHERE account_id = $1 AND expires_at > NOW()
+		ORDER BY created_at DESC`, cur.AccountID, cur.ID)
+	if err != nil {
+		return err
+	}
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (sessionItem, error) {
+		var it sessionItem
+		err := row.Scan(&it.ID, &it.CreatedAt, &it.ExpiresAt, &it.Current)
+		return it, err
+	})
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// logout — DELETE /sessions: завершает сессию, которой подписан запрос
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) error {
+	token, _ := bearerToken(r)
+	if err := s.auth.Logout(r.Context(), token, client(r)); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
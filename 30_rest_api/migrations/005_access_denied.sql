@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Отказы в доступе из проверки прав REST API (30_rest_api)
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check CHECK (action IN (
+    'login', 'logout', 'update_profile', 'delete_account',
+    'login_failed', 'account_locked', 'password_changed',
+    'access_denied'
+));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM audit_logs WHERE action = 'access_denied';
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check CHECK (action IN (
+    'login', 'logout', 'update_profile', 'delete_account',
+    'login_failed', 'account_locked', 'password_changed'
+));
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+// Package migrations — миграции 29_auth и действие аудита access_denied для проверки прав API.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/akozadaev/go_db_20/11_pgx_conn_pool_migration_goose/migrations"
+	authmigrations "github.com/akozadaev/go_db_20/29_auth/migrations"
+)
+
+//go:embed *.sql
+var own embed.FS
+
+// FS — миграции 001–004 из 29_auth (вместе с общими из урока 11) и миграция урока 005 в одном каталоге для goose.
+var FS = migrations.With(authmigrations.FS, own)
@@ -0,0 +1,7 @@
+// Package accountpb — сообщения и клиент/сервер AccountService, сгенерированные из account.proto.
+// После правки account.proto перегенерируйте код (нужны protoc, protoc-gen-go и protoc-gen-go-grpc):
+//
+//	go generate ./accountpb
+package accountpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative account.proto
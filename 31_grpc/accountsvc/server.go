@@ -0,0 +1,232 @@
+// Package accountsvc — реализация AccountService поверх pgxpool на схеме 10_pgx_conn_pool_migration.
+//
+// Ошибки переводятся в коды gRPC: невалидный запрос — InvalidArgument, нет записи — NotFound,
+// дубль — AlreadyExists, отмена и дедлайн клиента — Canceled и DeadlineExceeded.
+// Внутренние ошибки клиент видит как Internal без подробностей, подробности — в логе.
+package accountsvc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/akozadaev/go_db_20/10_pgx_conn_pool_migration/account"
+	"github.com/akozadaev/go_db_20/31_grpc/accountpb"
+)
+
+const defaultRole = "user"
+
+// maxID — accounts.id в схеме SERIAL (int4). Больший id pgx не закодирует в параметр,
+// поэтому он отсекается до запроса: такого аккаунта быть не может
+const maxID = math.MaxInt32
+
+type Server struct {
+	accountpb.UnimplementedAccountServiceServer
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Server {
+	return &Server{pool: pool}
+}
+
+// Register регистрирует сервис на gRPC-сервере
+func (s *Server) Register(srv *grpc.Server) {
+	accountpb.RegisterAccountServiceServer(srv, s)
+}
+
+// accountQuery — аккаунт с ролями одной строкой; фильтры добавляются перед GROUP BY
+const accountQuery = `
+	SELECT a.id, a.username, a.email, a.created_at,
+		COALESCE(array_agg(r.name ORDER BY r.name) FILTER (WHERE r.name IS NOT NULL), '{}')
+	FROM accounts a
+	LEFT JOIN account_roles ar ON ar.account_id = a.id
+	LEFT JOIN roles r ON r.id = ar.role_id
+`
+
+func scanAccount(row pgx.Row) (*accountpb.Account, error) {
+	var (
+		acc     accountpb.Account
+		created time.Time
+	)
+	if err := row.Scan(&acc.Id, &acc.Username, &acc.Email, &created, &acc.Roles); err != nil {
+		return nil, err
+	}
+	acc.CreatedAt = timestamppb.New(created)
+	return &acc, nil
+}
+
+func (s *Server) CreateAccount(ctx context.Context, req *accountpb.CreateAccountRequest) (*accountpb.Account, error) {
+	acc := account.Account{Username: req.GetUsername(), Email: req.GetEmail()}
+	if err := acc.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	role := req.GetRole()
+	if role == "" {
+		role = defaultRole
+	}
+
+	var id int64
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, "INSERT INTO accounts (username, email) VALUES ($1, $2) RETURNING id",
+			acc.Username, acc.Email).Scan(&id); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, "INSERT INTO account_roles (account_id, role_id) SELECT $1, id FROM roles WHERE name = $2", id, role)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return status.Errorf(codes.NotFound, "роль %q не найдена", role)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return s.GetAccount(ctx, &accountpb.GetAccountRequest{Id: id})
+}
+
+func (s *Server) GetAccount(ctx context.Context, req *accountpb.GetAccountRequest) (*accountpb.Account, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id должен быть положительным")
+	}
+	if req.GetId() > maxID {
+		return nil, status.Errorf(codes.NotFound, "аккаунт %d не найден", req.GetId())
+	}
+	acc, err := scanAccount(s.pool.QueryRow(ctx, accountQuery+"WHERE a.id = $1 GROUP BY a.id", req.GetId()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "аккаунт %d не найден", req.GetId())
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return acc, nil
+}
+
+// ListAccounts читает строки курсором pgx и сразу отправляет их клиенту.
+// Медленный клиент тормозит чтение (flow control HTTP/2), а не раздувает память сервера.
+func (s *Server) ListAccounts(req *accountpb.ListAccountsRequest, stream grpc.ServerStreamingServer[accountpb.Account]) error {
+	ctx := stream.Context()
+	if req.GetLimit() < 0 || req.GetAfterId() < 0 {
+		return status.Error(codes.InvalidArgument, "limit и after_id не могут быть отрицательными")
+	}
+	if req.GetAfterId() > maxID {
+		return status.Errorf(codes.InvalidArgument, "after_id не может быть больше %d", maxID)
+	}
+
+	rows, err := s.pool.Query(ctx, accountQuery+`
+		WHERE a.id > $1 AND starts_with(a.username, $2)
+		GROUP BY a.id
+		ORDER BY a.id
+		LIMIT NULLIF($3, 0)`, req.GetAfterId(), req.GetUsernamePrefix(), req.GetLimit())
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+		if err := stream.Send(acc); err != nil {
+			return err
+		}
+	}
+	return toStatus(ctx, rows.Err())
+}
+
+func (s *Server) AssignRole(ctx context.Context, req *accountpb.AssignRoleRequest) (*accountpb.AssignRoleResponse, error) {
+	if req.GetAccountId() <= 0 || req.GetRole() == "" {
+		return nil, status.Error(codes.InvalidArgument, "нужны account_id и role")
+	}
+	if req.GetAccountId() > maxID {
+		return nil, status.Errorf(codes.NotFound, "аккаунт %d не найден", req.GetAccountId())
+	}
+
+	var (
+		exists bool
+		roleID *int64
+	)
+	err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM accounts WHERE id = $1), (SELECT id FROM roles WHERE name = $2)`,
+		req.GetAccountId(), req.GetRole()).Scan(&exists, &roleID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "аккаунт %d не найден", req.GetAccountId())
+	}
+	if roleID == nil {
+		return nil, status.Errorf(codes.NotFound, "роль %q не найдена", req.GetRole())
+	}
+
+	tag, err := s.pool.Exec(ctx, "INSERT INTO account_roles (account_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		req.GetAccountId(), *roleID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &accountpb.AssignRoleResponse{Assigned: tag.RowsAffected() == 1}, nil
+}
+
+func (s *Server) RevokeSession(ctx context.Context, req *accountpb.RevokeSessionRequest) (*accountpb.RevokeSessionResponse, error) {
+	id, err := uuid.Parse(req.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "session_id должен быть UUID")
+	}
+	var accountID int64
+	err = s.pool.QueryRow(ctx, "DELETE FROM sessions WHERE id = $1 RETURNING account_id", id).Scan(&accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "сессия %s не найдена", id)
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &accountpb.RevokeSessionResponse{AccountId: accountID}, nil
+}
+
+// toStatus переводит ошибку базы в статус gRPC; готовый статус возвращается как есть
+func toStatus(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	// pgx не всегда оборачивает ошибку контекста — проверяем и сам контекст
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status.Error(codes.NotFound, "не найдено")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return status.Errorf(codes.AlreadyExists, "запись уже существует: %s", pgErr.ConstraintName)
+		case "23503": // foreign_key_violation — ссылка на удалённую параллельно запись
+			return status.Errorf(codes.FailedPrecondition, "ссылка на несуществующую запись: %s", pgErr.ConstraintName)
+		case "23514", "23502", "22001": // check, not null, слишком длинная строка
+			return status.Errorf(codes.InvalidArgument, "нарушено ограничение: %s", pgErr.Message)
+		}
+	}
+
+	slog.ErrorContext(ctx, "ошибка AccountService", "err", err)
+	return status.Error(codes.Internal, "внутренняя ошибка сервера")
+}